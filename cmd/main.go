@@ -5,15 +5,19 @@ import (
 	"fmt"
 	"log"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/broadcast80/ozon-task/config"
 	"github.com/broadcast80/ozon-task/domain/link"
 	app "github.com/broadcast80/ozon-task/internal/app"
-	"github.com/broadcast80/ozon-task/internal/pkg/utils"
-	inmemory "github.com/broadcast80/ozon-task/internal/repository/in_memory"
-	"github.com/broadcast80/ozon-task/internal/repository/postgresql"
+	"github.com/broadcast80/ozon-task/internal/bootstrap"
+	"github.com/broadcast80/ozon-task/internal/pkg/destination"
+	"github.com/broadcast80/ozon-task/internal/pkg/ratelimit"
+	"github.com/broadcast80/ozon-task/internal/pkg/tracing"
 	"github.com/broadcast80/ozon-task/internal/usecase"
 	"github.com/joho/godotenv"
 )
@@ -41,57 +45,146 @@ func main() {
 	log.Info("starting service")
 	log.Debug("debug messages are enabled")
 
-	ctx := context.TODO()
+	if err := usecase.ValidateIPSalt(cfg.AnalyticsConfig.IPSalt); err != nil {
+		log.Error("invalid analytics config", "Error", err.Error())
+		os.Exit(1)
+	}
+
+	// a batch takes a token per item, one larger than the burst never passes
+	if limits := cfg.RateLimitConfig; limits.BatchPerMinute > 0 && limits.BatchBurst < cfg.HTTPServer.MaxBatchSize {
+		log.Error("invalid rate limit config", "Error", "batch_burst is below max_batch_size")
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.TracingConfig)
+	if err != nil {
+		log.Error("failed to init tracing", "Error", err.Error())
+		os.Exit(1)
+	}
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTPServer.ShutdownTimeout)
+		defer cancel()
+
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			log.Error("failed to flush spans", "Error", err.Error())
+		}
+	}()
+
+	storage, err := bootstrap.NewStorage(ctx, *cfg, log)
+	if err != nil {
+		log.Error("failed to init storage", "Error", err.Error())
+		os.Exit(1)
+	}
+	defer storage.Close()
+
+	repository := storage.Repository
 
-	repository := newRepository(ctx, *cfg, log)
+	generator, err := bootstrap.NewGenerator(*cfg)
+	if err != nil {
+		log.Error("failed to init alias generator", "Error", err.Error())
+		os.Exit(1)
+	}
 
-	dataProvider := usecase.New(repository, log)
+	dataProvider := usecase.New(repository, generator, log, cfg.AliasConfig)
 
-	service := link.NewShortener(dataProvider)
+	if cfg.ReaperConfig.Interval > 0 {
+		reaper := usecase.NewReaper(repository, cfg.ReaperConfig.Interval, log)
+		reaper.Start()
+		defer reaper.Stop()
+	}
+
+	destinations := destination.New(net.DefaultResolver, cfg.DestinationConfig.BlockPrivate, cfg.DestinationConfig.ResolveTimeout)
+	if cfg.DestinationConfig.ListFile != "" {
+		err := destinations.Watch(cfg.DestinationConfig.ListFile, cfg.DestinationConfig.ReloadInterval, log)
+		if err != nil {
+			log.Error("failed to load destination lists", "Error", err.Error())
+			os.Exit(1)
+		}
+		defer destinations.Stop()
+	}
+
+	dataProvider.SetDestinations(destinations)
+	service := link.NewShortener(dataProvider, destinations)
 
 	router := http.NewServeMux()
 
-	handlers := app.New(router, service, log)
+	analytics := usecase.NewAnalytics(storage.Stats, cfg.AnalyticsConfig, log)
+	analytics.Start()
+	defer analytics.Stop()
+
+	handlers := app.New(router, service, analytics, log, cfg.HTTPServer.RedirectStatus)
+
+	if err = handlers.SetTrustedProxies(cfg.RateLimitConfig.TrustedProxies); err != nil {
+		log.Error("failed to configure rate limiting", "Error", err.Error())
+		os.Exit(1)
+	}
+	create, resolve, batch, authenticate := newRateLimiters(cfg.RateLimitConfig)
+	handlers.SetRateLimiters(create, resolve)
+	handlers.SetBatchLimiter(batch)
+	handlers.SetAuthLimiter(authenticate)
+	handlers.SetMaxBatchSize(cfg.HTTPServer.MaxBatchSize)
+	handlers.SetTransfer(dataProvider)
+	if cfg.AuthConfig.Enabled {
+		handlers.SetKeyStore(storage.Keys)
+	}
 
 	if err = handlers.MapHandlers(); err != nil {
-		log.Error("failed to map handlers")
+		log.Error("failed to map handlers", "Error", err.Error())
+		os.Exit(1)
 	}
 
-	errs := make(chan error, 2)
+	handlers.AddReadinessCheck("storage", storage.Ping)
+
+	server := app.NewServer(cfg.HTTPServer, handlers.Handler())
+
+	errs := make(chan error, 1)
 
 	go func() {
-		errs <- handlers.ListenAndServe(cfg.HTTPServer.Port)
+		log.Info("listening", "address", server.Addr)
+		errs <- app.ListenAndServe(server)
 	}()
 
-	err = <-errs
-	if err != nil {
-		fmt.Printf("Werr %s", err.Error())
-	}
-}
+	handlers.SetReady(true)
 
-func newRepository(ctx context.Context, cfg config.Config, log *slog.Logger) usecase.RepositoryInterface {
-	storageType := os.Getenv("STORAGE_TYPE")
-	if storageType == "" {
-		storageType = "inmemory"
-	}
+	select {
+	case err = <-errs:
+		if err != nil {
+			log.Error("server stopped", "Error", err.Error())
+		}
+	case <-ctx.Done():
+		log.Info("shutting down", "timeout", cfg.HTTPServer.ShutdownTimeout)
 
-	switch storageType {
+		// fail readiness first so load balancers stop routing new requests
+		// while in-flight ones drain
+		handlers.SetReady(false)
 
-	case "postgres":
-		postgreSQLClient, err := utils.NewClient(ctx, 5, cfg.PostgresConfig)
-		if err != nil {
-			log.Error("failed to init storage", "Error", err.Error())
-			os.Exit(1)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTPServer.ShutdownTimeout)
+		defer cancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Error("failed to drain requests", "Error", err.Error())
 		}
-		repository := postgresql.New(postgreSQLClient)
-		return repository
+	}
 
-	case "inmemory":
-		repository := inmemory.New(cfg.InMemoryConfig.Size)
-		return repository
+	// deferred in reverse order: reaper and analytics stop and flush first,
+	// then the storage is closed
+}
 
-	default:
-		log.Error("uknown STORAGE_TYPE", "STORAGE_TYPE", storageType)
-		return nil
+func newRateLimiters(cfg config.RateLimitConfig) (create, resolve, batch, authenticate app.RateLimiter) {
+	if cfg.CreatePerMinute > 0 {
+		create = ratelimit.PerMinute(cfg.CreatePerMinute, cfg.CreateBurst)
+	}
+	if cfg.BatchPerMinute > 0 {
+		batch = ratelimit.PerMinute(cfg.BatchPerMinute, cfg.BatchBurst)
+	}
+	if cfg.ResolvePerMinute > 0 {
+		resolve = ratelimit.PerMinute(cfg.ResolvePerMinute, cfg.ResolveBurst)
+	}
+	if cfg.AuthPerMinute > 0 {
+		authenticate = ratelimit.PerMinute(cfg.AuthPerMinute, cfg.AuthBurst)
 	}
+	return create, resolve, batch, authenticate
 }
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+
+	"github.com/broadcast80/ozon-task/config"
+	"github.com/broadcast80/ozon-task/domain/link"
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	app "github.com/broadcast80/ozon-task/internal/app"
+	"github.com/broadcast80/ozon-task/internal/bootstrap"
+	"github.com/broadcast80/ozon-task/internal/pkg/destination"
+	"github.com/broadcast80/ozon-task/internal/usecase"
+)
+
+// errUsage reports bad arguments after the usage has been printed.
+var errUsage = errors.New("usage")
+
+// service is the use case layer as ozonctl uses it.
+type service interface {
+	modellink.DataProvider
+	app.Transfer
+}
+
+// env holds what the commands share. The storage and the destination
+// checker are set up on first use, so commands that do not need them, like
+// migrate, never touch them.
+type env struct {
+	cfg    config.Config
+	log    *slog.Logger
+	output string
+
+	storage *bootstrap.Storage
+	checker *destination.Checker
+}
+
+// openStorage opens the configured storage once. An in-memory storage
+// without a persistence directory is refused, nothing would outlive the
+// command. A directory held by a running server is refused by the store
+// itself.
+func (e *env) openStorage(ctx context.Context) (*bootstrap.Storage, error) {
+	if e.storage != nil {
+		return e.storage, nil
+	}
+
+	storage, err := bootstrap.NewStorage(ctx, e.cfg, e.log)
+	if err != nil {
+		return nil, err
+	}
+	if !storage.Durable {
+		storage.Close()
+		return nil, errors.New("in-memory storage without persistence_dir keeps nothing between runs")
+	}
+
+	e.storage = &storage
+
+	return e.storage, nil
+}
+
+// service builds the use case layer on top of the storage, the way the
+// server does.
+func (e *env) service(ctx context.Context) (service, error) {
+	storage, err := e.openStorage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	generator, err := bootstrap.NewGenerator(e.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	destinations, err := e.destinations()
+	if err != nil {
+		return nil, err
+	}
+
+	service := usecase.New(storage.Repository, generator, e.log, e.cfg.AliasConfig)
+	service.SetDestinations(destinations)
+
+	return service, nil
+}
+
+// shortener validates and checks new links like the server.
+func (e *env) shortener(ctx context.Context) (*link.Shortener, error) {
+	service, err := e.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	destinations, err := e.destinations()
+	if err != nil {
+		return nil, err
+	}
+
+	return link.NewShortener(service, destinations), nil
+}
+
+// destinations returns the destination checker of the server. The lists
+// are read once instead of being watched.
+func (e *env) destinations() (*destination.Checker, error) {
+	if e.checker != nil {
+		return e.checker, nil
+	}
+
+	cfg := e.cfg.DestinationConfig
+	checker := destination.New(net.DefaultResolver, cfg.BlockPrivate, cfg.ResolveTimeout)
+	if cfg.ListFile != "" {
+		lists, err := destination.LoadLists(cfg.ListFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load destination lists: %w", err)
+		}
+		checker.SetLists(lists)
+	}
+
+	e.checker = checker
+
+	return e.checker, nil
+}
+
+func (e *env) close() {
+	if e.storage != nil {
+		e.storage.Close()
+	}
+}
+
+func (e *env) printer() (printer, error) {
+	switch e.output {
+	case "json", "table":
+		return printer{w: os.Stdout, json: e.output == "json"}, nil
+	default:
+		return printer{}, fmt.Errorf("unknown output %q, want json or table", e.output)
+	}
+}
+
+// parseArgs parses flags wherever they appear among the positional
+// arguments and returns the positional ones, so both "get abc -output
+// json" and "get -output json abc" work.
+func parseArgs(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		if flags.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+// newFlags returns the flag set of command, which accepts -output too.
+func (e *env) newFlags(command string) *flag.FlagSet {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.StringVar(&e.output, "output", e.output, "output format: json or table")
+	return flags
+}
+
+// exactArgs checks the number of positional arguments of command.
+func exactArgs(command string, args []string, n int, names string) error {
+	if len(args) != n {
+		fmt.Fprintf(os.Stderr, "usage: ozonctl %s %s\n", command, names)
+		return errUsage
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		positional []string
+		output     string
+		owner      string
+	}{
+		{name: "flags first", args: []string{"-output", "json", "-owner", "grace", "abc"}, positional: []string{"abc"}, output: "json", owner: "grace"},
+		{name: "flags last", args: []string{"abc", "-owner=grace"}, positional: []string{"abc"}, output: "table", owner: "grace"},
+		{name: "interleaved", args: []string{"a", "-output", "json", "b"}, positional: []string{"a", "b"}, output: "json"},
+		{name: "terminator", args: []string{"--", "-a"}, positional: []string{"-a"}, output: "table"},
+		{name: "none", output: "table"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &env{output: "table"}
+			flags := e.newFlags("test")
+			owner := flags.String("owner", "", "")
+
+			positional, err := parseArgs(flags, tt.args)
+			require.NoError(t, err)
+			require.Equal(t, tt.positional, positional)
+			require.Equal(t, tt.output, e.output)
+			require.Equal(t, tt.owner, *owner)
+		})
+	}
+}
+
+func TestParseArgs_UnknownFlag(t *testing.T) {
+	flags := (&env{}).newFlags("test")
+	flags.SetOutput(io.Discard)
+
+	_, err := parseArgs(flags, []string{"abc", "-bogus"})
+	require.Error(t, err)
+
+	_, err = parseArgs(flags, []string{"-h"})
+	require.ErrorIs(t, err, flag.ErrHelp)
+}
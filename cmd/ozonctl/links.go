@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/broadcast80/ozon-task/db"
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/bootstrap"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+	"github.com/broadcast80/ozon-task/internal/repository/postgresql"
+	"github.com/broadcast80/ozon-task/internal/usecase"
+)
+
+// listPageSize is the page size of list -all.
+const listPageSize = 500
+
+func runCreate(ctx context.Context, e *env, args []string) error {
+	flags := e.newFlags("create")
+	url := flags.String("url", "", "URL to shorten")
+	alias := flags.String("alias", "", "custom alias, generated when empty")
+	owner := flags.String("owner", "", "owner of the link")
+	ttl := flags.Duration("ttl", 0, "lifetime of the link, e.g. 24h")
+	expires := flags.String("expires-at", "", "expiry time in RFC 3339")
+
+	args, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if err := exactArgs("create", args, 0, "-url URL [flags]"); err != nil {
+		return err
+	}
+
+	p, err := e.printer()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	request := modellink.Link{URL: *url, Alias: *alias, Owner: *owner, CreatedAt: now}
+
+	switch {
+	case *ttl != 0 && *expires != "":
+		return errors.New("-ttl and -expires-at are mutually exclusive")
+	case *ttl < 0:
+		return errors.New("-ttl must be positive")
+	case *ttl > 0:
+		expiresAt := now.Add(*ttl)
+		request.ExpiresAt = &expiresAt
+	case *expires != "":
+		expiresAt, err := time.Parse(time.RFC3339, *expires)
+		if err != nil {
+			return errors.New("-expires-at must be RFC 3339")
+		}
+		if !expiresAt.After(now) {
+			return errors.New("-expires-at must be in the future")
+		}
+		request.ExpiresAt = &expiresAt
+	}
+
+	shortener, err := e.shortener(ctx)
+	if err != nil {
+		return err
+	}
+
+	link, created, err := shortener.CutLink(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	response := newResponse(link)
+	t := linkTable([]models.Response{response})
+	t.header = append(t.header, "CREATED_NOW")
+	t.rows[0] = append(t.rows[0], strconv.FormatBool(created))
+
+	return p.print(struct {
+		models.Response
+		Created bool `json:"created"`
+	}{response, created}, t)
+}
+
+func runGet(ctx context.Context, e *env, args []string) error {
+	args, err := parseArgs(e.newFlags("get"), args)
+	if err != nil {
+		return err
+	}
+	if err := exactArgs("get", args, 1, "ALIAS"); err != nil {
+		return err
+	}
+
+	p, err := e.printer()
+	if err != nil {
+		return err
+	}
+
+	storage, err := e.openStorage(ctx)
+	if err != nil {
+		return err
+	}
+
+	// straight from the repository: expired and disabled links are shown
+	// as they are
+	link, err := storage.Repository.Get(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	response := newResponse(link)
+
+	return p.print(response, linkTable([]models.Response{response}))
+}
+
+func runDelete(ctx context.Context, e *env, args []string) error {
+	args, err := parseArgs(e.newFlags("delete"), args)
+	if err != nil {
+		return err
+	}
+	if err := exactArgs("delete", args, 1, "ALIAS"); err != nil {
+		return err
+	}
+
+	p, err := e.printer()
+	if err != nil {
+		return err
+	}
+
+	storage, err := e.openStorage(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := storage.Repository.Delete(ctx, args[0]); err != nil {
+		return err
+	}
+
+	return p.print(
+		map[string]string{"deleted": args[0]},
+		table{header: []string{"DELETED"}, rows: [][]string{{args[0]}}},
+	)
+}
+
+func runList(ctx context.Context, e *env, args []string) error {
+	flags := e.newFlags("list")
+	owner := flags.String("owner", "", "only links of this owner")
+	domain := flags.String("domain", "", "only links to this domain and its subdomains")
+	url := flags.String("url", "", "only URLs containing this substring")
+	limit := flags.Int("limit", 50, "maximum number of links, newest first")
+	all := flags.Bool("all", false, "list every matching link, oldest first")
+
+	args, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if err := exactArgs("list", args, 0, "[flags]"); err != nil {
+		return err
+	}
+	if *limit <= 0 {
+		return errors.New("-limit must be positive")
+	}
+
+	p, err := e.printer()
+	if err != nil {
+		return err
+	}
+
+	storage, err := e.openStorage(ctx)
+	if err != nil {
+		return err
+	}
+
+	filter := modellink.Filter{Owner: *owner, Domain: *domain, URLContains: *url, Limit: *limit}
+	if *all {
+		filter.Ascending, filter.Limit = true, listPageSize
+	}
+
+	links := []models.Response{}
+	for {
+		page, err := storage.Repository.List(ctx, filter)
+		if err != nil {
+			return err
+		}
+
+		for _, link := range page.Links {
+			links = append(links, newResponse(&link))
+		}
+
+		if !*all || page.Next == nil {
+			break
+		}
+		filter.After = page.Next
+	}
+
+	return p.print(links, linkTable(links))
+}
+
+func runStats(ctx context.Context, e *env, args []string) error {
+	args, err := parseArgs(e.newFlags("stats"), args)
+	if err != nil {
+		return err
+	}
+	if err := exactArgs("stats", args, 1, "ALIAS"); err != nil {
+		return err
+	}
+
+	p, err := e.printer()
+	if err != nil {
+		return err
+	}
+
+	storage, err := e.openStorage(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := storage.Repository.Get(ctx, args[0]); err != nil {
+		return err
+	}
+
+	stats, err := usecase.NewAnalytics(storage.Stats, e.cfg.AnalyticsConfig, e.log).Stats(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	summary := table{
+		header: []string{"ALIAS", "TOTAL", "LAST_ACCESS"},
+		rows:   [][]string{{stats.Alias, strconv.FormatInt(stats.Total, 10), formatTime(stats.LastAccessAt)}},
+	}
+	daily := table{header: []string{"DATE", "HITS"}}
+	for _, day := range stats.Daily {
+		daily.rows = append(daily.rows, []string{day.Date, strconv.FormatInt(day.Count, 10)})
+	}
+
+	return p.print(stats, summary, daily)
+}
+
+func runReap(ctx context.Context, e *env, args []string) error {
+	args, err := parseArgs(e.newFlags("reap"), args)
+	if err != nil {
+		return err
+	}
+	if err := exactArgs("reap", args, 0, ""); err != nil {
+		return err
+	}
+
+	p, err := e.printer()
+	if err != nil {
+		return err
+	}
+
+	storage, err := e.openStorage(ctx)
+	if err != nil {
+		return err
+	}
+
+	removed, err := usecase.NewReaper(storage.Repository, 0, e.log).Reap(ctx)
+	if err != nil {
+		return err
+	}
+
+	return p.print(
+		map[string]int64{"removed": removed},
+		table{header: []string{"REMOVED"}, rows: [][]string{{strconv.FormatInt(removed, 10)}}},
+	)
+}
+
+// runMigrate applies the embedded migrations to the configured PostgreSQL
+// database, the in-memory storage has no schema.
+func runMigrate(ctx context.Context, e *env, args []string) error {
+	args, err := parseArgs(e.newFlags("migrate"), args)
+	if err != nil {
+		return err
+	}
+	if err := exactArgs("migrate", args, 0, ""); err != nil {
+		return err
+	}
+
+	p, err := e.printer()
+	if err != nil {
+		return err
+	}
+
+	if storageType := bootstrap.StorageType(); storageType != bootstrap.StoragePostgres {
+		return fmt.Errorf("migrations apply to postgres storage only, STORAGE_TYPE is %s", storageType)
+	}
+
+	client, err := bootstrap.NewPostgresClient(ctx, e.cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	applied, err := postgresql.Migrate(ctx, client, db.Migrations)
+	if err != nil {
+		return fmt.Errorf("%w (applied before it: %v)", err, applied)
+	}
+
+	t := table{header: []string{"APPLIED"}}
+	for _, name := range applied {
+		t.rows = append(t.rows, []string{name})
+	}
+
+	return p.print(map[string][]string{"applied": append([]string{}, applied...)}, t)
+}
@@ -0,0 +1,107 @@
+// Command ozonctl operates the shortener storage directly, without a
+// running server. It reads the same configuration as the server.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/broadcast80/ozon-task/config"
+	"github.com/broadcast80/ozon-task/internal/pkg/auth"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+	"github.com/joho/godotenv"
+)
+
+const usage = `usage: ozonctl [-output json|table] <command> [flags]
+
+commands:
+  create   -url URL [-alias ALIAS] [-owner OWNER] [-ttl DURATION | -expires-at TIME]
+  get      ALIAS
+  delete   ALIAS
+  list     [-owner OWNER] [-domain DOMAIN] [-url SUBSTRING] [-limit N] [-all]
+  stats    ALIAS
+  reap
+  migrate
+  export   [-format jsonl|csv] [-out FILE]
+  import   [-format jsonl|csv] [-on-conflict skip|overwrite|fail] [-in FILE]
+
+The storage is selected by STORAGE_TYPE and CONFIG_PATH like for the
+server, ENV_PATH is loaded when set.`
+
+// command runs with the arguments left after its name.
+type command func(ctx context.Context, e *env, args []string) error
+
+var commands = map[string]command{
+	"create":  runCreate,
+	"get":     runGet,
+	"delete":  runDelete,
+	"list":    runList,
+	"stats":   runStats,
+	"reap":    runReap,
+	"migrate": runMigrate,
+	"export":  runExport,
+	"import":  runImport,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	flags := flag.NewFlagSet("ozonctl", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprintln(os.Stderr, usage) }
+	output := flags.String("output", "table", "output format: json or table")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	name := flags.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		flags.Usage()
+		return 2
+	}
+
+	if envPath := os.Getenv("ENV_PATH"); envPath != "" {
+		if err := godotenv.Load(envPath); err != nil {
+			fmt.Fprintf(os.Stderr, "error loading .env file from path %s: %v\n", envPath, err)
+			return 1
+		}
+	}
+
+	e := &env{
+		cfg:    *config.MustLoad(),
+		log:    slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})),
+		output: *output,
+	}
+	defer e.close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// whoever can run ozonctl already has the storage, it acts as an admin
+	ctx = auth.WithPrincipal(ctx, models.Principal{Owner: "ozonctl", Admin: true})
+
+	err := cmd(ctx, e, flags.Args()[1:])
+	switch {
+	case errors.Is(err, flag.ErrHelp), errors.Is(err, errUsage):
+		return 2
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		return 1
+	}
+
+	return 0
+}
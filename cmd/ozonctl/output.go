@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+// table is one block of table output.
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+// printer writes a result as indented JSON, or as tables separated by an
+// empty line.
+type printer struct {
+	w    io.Writer
+	json bool
+}
+
+func (p printer) print(v any, tables ...table) error {
+	if p.json {
+		encoder := json.NewEncoder(p.w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	for i, t := range tables {
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+		fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+		for _, row := range t.rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+	}
+
+	return tw.Flush()
+}
+
+func newResponse(link *modellink.Link) models.Response {
+	return models.Response{
+		URL:       link.URL,
+		Alias:     link.Alias,
+		ExpiresAt: link.ExpiresAt,
+		Owner:     link.Owner,
+		Disabled:  link.Disabled,
+		CreatedAt: link.CreatedAt,
+	}
+}
+
+func linkTable(links []models.Response) table {
+	t := table{header: []string{"ALIAS", "URL", "OWNER", "CREATED", "EXPIRES", "DISABLED"}}
+	for _, link := range links {
+		t.rows = append(t.rows, []string{
+			link.Alias,
+			link.URL,
+			dash(link.Owner),
+			formatTime(&link.CreatedAt),
+			formatTime(link.ExpiresAt),
+			strconv.FormatBool(link.Disabled),
+		})
+	}
+	return t
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
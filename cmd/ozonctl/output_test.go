@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrinter(t *testing.T) {
+	expiresAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	links := []models.Response{
+		{URL: "https://example.com", Alias: "abc", Owner: "grace", CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), ExpiresAt: &expiresAt},
+		{URL: "https://example.org/long", Alias: "d", CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Disabled: true},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, printer{w: &buf}.print(links, linkTable(links), table{header: []string{"TOTAL"}, rows: [][]string{{"2"}}}))
+	require.Equal(t, ""+
+		"ALIAS  URL                       OWNER  CREATED               EXPIRES               DISABLED\n"+
+		"abc    https://example.com       grace  2024-01-01T00:00:00Z  2024-01-02T03:04:05Z  false\n"+
+		"d      https://example.org/long  -      2024-01-01T00:00:00Z  -                     true\n"+
+		"\n"+
+		"TOTAL\n"+
+		"2\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, printer{w: &buf, json: true}.print(links[:1], linkTable(links[:1])))
+	require.JSONEq(t, `[{
+		"url": "https://example.com",
+		"alias": "abc",
+		"owner": "grace",
+		"created_at": "2024-01-01T00:00:00Z",
+		"expires_at": "2024-01-02T03:04:05Z"
+	}]`, buf.String())
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+	"github.com/broadcast80/ozon-task/internal/pkg/transfer"
+)
+
+// runExport writes the links themselves to stdout or -out, so -output
+// does not apply.
+func runExport(ctx context.Context, e *env, args []string) error {
+	flags := e.newFlags("export")
+	formatFlag := flags.String("format", "jsonl", "output format: jsonl or csv")
+	out := flags.String("out", "-", "output file, - for stdout")
+
+	args, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if err := exactArgs("export", args, 0, "[flags]"); err != nil {
+		return err
+	}
+
+	format, err := transfer.ParseFormat(*formatFlag)
+	if err != nil {
+		return err
+	}
+
+	service, err := e.service(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := os.Stdout
+	if *out != "-" {
+		if w, err = os.Create(*out); err != nil {
+			return err
+		}
+		defer w.Close()
+	}
+
+	if _, err := service.ExportLinks(ctx, transfer.NewWriter(w, format)); err != nil {
+		return err
+	}
+
+	if w != os.Stdout {
+		return w.Close()
+	}
+
+	return nil
+}
+
+func runImport(ctx context.Context, e *env, args []string) error {
+	flags := e.newFlags("import")
+	formatFlag := flags.String("format", "jsonl", "input format: jsonl or csv")
+	onConflict := flags.String("on-conflict", "fail", "taken aliases: skip, overwrite or fail")
+	in := flags.String("in", "-", "input file, - for stdin")
+
+	args, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if err := exactArgs("import", args, 0, "[flags]"); err != nil {
+		return err
+	}
+
+	p, err := e.printer()
+	if err != nil {
+		return err
+	}
+
+	format, err := transfer.ParseFormat(*formatFlag)
+	if err != nil {
+		return err
+	}
+
+	policy, err := transfer.ParsePolicy(*onConflict)
+	if err != nil {
+		return err
+	}
+
+	r := os.Stdin
+	if *in != "-" {
+		if r, err = os.Open(*in); err != nil {
+			return err
+		}
+		defer r.Close()
+	}
+
+	service, err := e.service(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := service.ImportLinks(ctx, transfer.NewReader(r, format), policy)
+	if err != nil {
+		return fmt.Errorf("%w (%d imported, %d skipped before it)", err, result.Imported, result.Skipped)
+	}
+
+	response := models.ImportResponse{Imported: result.Imported, Skipped: result.Skipped}
+
+	return p.print(response, table{
+		header: []string{"IMPORTED", "SKIPPED"},
+		rows:   [][]string{{fmt.Sprint(response.Imported), fmt.Sprint(response.Skipped)}},
+	})
+}
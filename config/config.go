@@ -9,16 +9,34 @@ import (
 )
 
 type Config struct {
-	HTTPServer     `yaml:"http_server"`
-	PostgresConfig `yaml:"postgres_config"`
-	InMemoryConfig `yaml:"inmemory_config"`
+	HTTPServer        `yaml:"http_server"`
+	PostgresConfig    `yaml:"postgres_config"`
+	InMemoryConfig    `yaml:"inmemory_config"`
+	AliasConfig       `yaml:"alias_config"`
+	GeneratorConfig   `yaml:"generator_config"`
+	ReaperConfig      `yaml:"reaper_config"`
+	AnalyticsConfig   `yaml:"analytics_config"`
+	CacheConfig       `yaml:"cache_config"`
+	TracingConfig     `yaml:"tracing_config"`
+	RateLimitConfig   `yaml:"rate_limit_config"`
+	AuthConfig        `yaml:"auth_config"`
+	DestinationConfig `yaml:"destination_config"`
 }
 
 type HTTPServer struct {
-	Host         string        `yaml:"host"`
-	Port         string        `yaml:"port"`
-	Timeout      time.Duration `yaml:"timeout"`
-	Idle_timeout time.Duration `yaml:"idle_timeout"`
+	Host string `yaml:"host"`
+	Port string `yaml:"port"`
+	// Timeout bounds reading a request and writing its response.
+	Timeout           time.Duration `yaml:"timeout" env-default:"4s"`
+	Idle_timeout      time.Duration `yaml:"idle_timeout" env-default:"60s"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env-default:"2s"`
+	MaxHeaderBytes    int           `yaml:"max_header_bytes" env-default:"65536"`
+	// ShutdownTimeout is how long in-flight requests may drain on SIGINT/SIGTERM.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env-default:"10s"`
+	// RedirectStatus is the status code used by GET /{alias}: 301, 302, 307 or 308.
+	RedirectStatus int `yaml:"redirect_status" env:"REDIRECT_STATUS" env-default:"302"`
+	// MaxBatchSize caps the number of items of POST /api/v1/links:batch.
+	MaxBatchSize int `yaml:"max_batch_size" env:"MAX_BATCH_SIZE" env-default:"1000"`
 }
 
 type PostgresConfig struct {
@@ -31,6 +49,103 @@ type PostgresConfig struct {
 
 type InMemoryConfig struct {
 	Size int `yaml:"size"`
+	// Eviction is applied once Size links are stored: reject, lru or oldest.
+	Eviction string `yaml:"eviction" env:"INMEMORY_EVICTION" env-default:"reject"`
+	// PersistenceDir enables the journal and snapshots, empty keeps links
+	// in memory only.
+	PersistenceDir   string        `yaml:"persistence_dir" env:"INMEMORY_PERSISTENCE_DIR"`
+	SnapshotInterval time.Duration `yaml:"snapshot_interval" env-default:"5m"`
+	Fsync            bool          `yaml:"fsync"`
+}
+
+// AliasConfig restricts aliases supplied by clients.
+type AliasConfig struct {
+	Charset   string   `yaml:"charset" env-default:"abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_"`
+	MinLength int      `yaml:"min_length" env-default:"3"`
+	MaxLength int      `yaml:"max_length" env-default:"32"`
+	Reserved  []string `yaml:"reserved" env-default:"api,health,healthz,readyz,metrics"`
+}
+
+// GeneratorConfig selects how aliases are generated when the client
+// doesn't supply one: random, hash or counter.
+type GeneratorConfig struct {
+	Type         string `yaml:"type" env:"ALIAS_GENERATOR" env-default:"random"`
+	Length       int    `yaml:"length" env-default:"10"`
+	Hash         string `yaml:"hash" env-default:"sha256"`
+	CounterStart uint64 `yaml:"counter_start"`
+}
+
+// ReaperConfig controls the background purge of expired links.
+// A zero interval disables the reaper.
+type ReaperConfig struct {
+	Interval time.Duration `yaml:"interval" env:"REAPER_INTERVAL" env-default:"1m"`
+}
+
+// AnalyticsConfig tunes the asynchronous click recording pipeline.
+type AnalyticsConfig struct {
+	BufferSize    int           `yaml:"buffer_size" env-default:"10000"`
+	BatchSize     int           `yaml:"batch_size" env-default:"500"`
+	FlushInterval time.Duration `yaml:"flush_interval" env-default:"1s"`
+	HistogramDays int           `yaml:"histogram_days" env-default:"30"`
+	// IPSalt is the secret mixed into client IP hashes, required.
+	IPSalt string `yaml:"ip_salt" env:"ANALYTICS_IP_SALT"`
+}
+
+// CacheConfig sizes the alias lookup cache in front of PostgreSQL. A zero
+// size disables it.
+type CacheConfig struct {
+	Size        int           `yaml:"size" env:"CACHE_SIZE" env-default:"10000"`
+	TTL         time.Duration `yaml:"ttl" env-default:"5m"`
+	NegativeTTL time.Duration `yaml:"negative_ttl" env-default:"30s"`
+}
+
+// TracingConfig selects where spans are exported: "none", "stdout" or
+// "otlp". An empty endpoint leaves the OTLP exporter to the standard
+// OTEL_EXPORTER_OTLP_* variables.
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER" env-default:"none"`
+	Endpoint    string  `yaml:"endpoint" env:"TRACING_ENDPOINT"`
+	Insecure    bool    `yaml:"insecure" env:"TRACING_INSECURE"`
+	ServiceName string  `yaml:"service_name" env-default:"ozon-task"`
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`
+}
+
+// RateLimitConfig throttles each client to a number of requests per minute
+// with a burst allowance. A zero limit disables throttling of that group.
+// Batches are counted in items, BatchBurst must fit a full batch.
+type RateLimitConfig struct {
+	CreatePerMinute  int      `yaml:"create_per_minute" env:"RATE_LIMIT_CREATE" env-default:"30"`
+	CreateBurst      int      `yaml:"create_burst" env-default:"10"`
+	BatchPerMinute   int      `yaml:"batch_per_minute" env:"RATE_LIMIT_BATCH" env-default:"1000"`
+	BatchBurst       int      `yaml:"batch_burst" env-default:"1000"`
+	ResolvePerMinute int      `yaml:"resolve_per_minute" env:"RATE_LIMIT_RESOLVE" env-default:"600"`
+	ResolveBurst     int      `yaml:"resolve_burst" env-default:"100"`
+	AuthPerMinute    int      `yaml:"auth_per_minute" env:"RATE_LIMIT_AUTH" env-default:"600"`
+	AuthBurst        int      `yaml:"auth_burst" env-default:"100"`
+	TrustedProxies   []string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES" env-separator:","`
+}
+
+// AuthConfig enables API key authentication. Keys listed here are used with
+// the in-memory storage, PostgreSQL reads them from the api_key table.
+type AuthConfig struct {
+	Enabled bool           `yaml:"enabled" env:"AUTH_ENABLED"`
+	Keys    []APIKeyConfig `yaml:"keys"`
+}
+
+type APIKeyConfig struct {
+	Owner string `yaml:"owner"`
+	// Hash is the hex SHA-256 of the key, the key itself is never stored.
+	Hash  string `yaml:"hash"`
+	Admin bool   `yaml:"admin"`
+}
+
+// DestinationConfig controls which URLs may be shortened. ListFile holds
+// "allow <domain>" and "deny <domain>" rules and is reloaded on change.
+type DestinationConfig struct {
+	BlockPrivate   bool          `yaml:"block_private" env:"DESTINATION_BLOCK_PRIVATE" env-default:"true"`
+	ListFile       string        `yaml:"list_file" env:"DESTINATION_LIST_FILE"`
+	ReloadInterval time.Duration `yaml:"reload_interval" env-default:"10s"`
+	ResolveTimeout time.Duration `yaml:"resolve_timeout" env-default:"2s"`
 }
 
 func MustLoad() *Config {
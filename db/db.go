@@ -0,0 +1,14 @@
+// Package db embeds the PostgreSQL schema migrations, the same files the
+// database container runs on first start.
+package db
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed migrations/*.sql
+var files embed.FS
+
+// Migrations holds the migration files, applied in name order.
+var Migrations, _ = fs.Sub(files, "migrations")
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS link_url_idx ON public.link (url)
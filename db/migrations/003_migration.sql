@@ -0,0 +1,3 @@
+ALTER TABLE public.link ADD COLUMN IF NOT EXISTS expires_at timestamptz NULL;
+
+CREATE INDEX IF NOT EXISTS link_expires_at_idx ON public.link (expires_at) WHERE expires_at IS NOT NULL
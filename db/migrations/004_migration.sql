@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS public.link_hit (
+	id bigserial NOT NULL,
+	alias text NOT NULL,
+	at timestamptz NOT NULL,
+	referer text NOT NULL DEFAULT '',
+	user_agent text NOT NULL DEFAULT '',
+	ip_hash text NOT NULL DEFAULT '',
+	CONSTRAINT link_hit_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS link_hit_alias_at_idx ON public.link_hit (alias, at)
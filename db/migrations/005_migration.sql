@@ -0,0 +1,14 @@
+ALTER TABLE public.link ADD COLUMN IF NOT EXISTS owner text NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS link_owner_url_idx ON public.link (owner, url);
+
+CREATE TABLE IF NOT EXISTS public.api_key (
+	id serial4 NOT NULL,
+	key_hash text NOT NULL,
+	owner text NOT NULL,
+	admin bool NOT NULL DEFAULT false,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	revoked_at timestamptz NULL,
+	CONSTRAINT api_key_pkey PRIMARY KEY (id),
+	CONSTRAINT api_key_hash_unique UNIQUE (key_hash)
+)
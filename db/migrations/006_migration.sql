@@ -0,0 +1 @@
+ALTER TABLE public.link ADD COLUMN IF NOT EXISTS disabled bool NOT NULL DEFAULT false
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS public.link_revision (
+	id bigserial NOT NULL,
+	link_id int4 NOT NULL,
+	url text NOT NULL,
+	replaced_at timestamptz NOT NULL,
+	CONSTRAINT link_revision_pkey PRIMARY KEY (id),
+	CONSTRAINT link_revision_link_fk FOREIGN KEY (link_id) REFERENCES public.link (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS link_revision_link_id_idx ON public.link_revision (link_id, id)
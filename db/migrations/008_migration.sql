@@ -0,0 +1,5 @@
+ALTER TABLE public.link
+	ALTER COLUMN created_at TYPE timestamptz,
+	ALTER COLUMN created_at SET DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS link_created_at_id_idx ON public.link (created_at, id)
@@ -3,48 +3,257 @@ package link
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+	"github.com/broadcast80/ozon-task/internal/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"golang.org/x/sync/errgroup"
 )
 
+// batchChecks bounds concurrent destination checks of a batch, each may
+// resolve a host.
+const batchChecks = 16
+
+var tracer = otel.Tracer("github.com/broadcast80/ozon-task/domain/link")
+
+// DestinationChecker rejects URLs that must not be shortened, e.g. ones
+// pointing into internal networks.
+type DestinationChecker interface {
+	Check(ctx context.Context, url string) error
+}
+
 type Shortener struct {
 	linkDataProvider modellink.DataProvider
+	destinations     DestinationChecker
 }
 
-func NewShortener(dataProvider modellink.DataProvider) *Shortener {
+// NewShortener returns a Shortener, a nil checker accepts every valid URL.
+func NewShortener(dataProvider modellink.DataProvider, destinations DestinationChecker) *Shortener {
 	return &Shortener{
 		linkDataProvider: dataProvider,
+		destinations:     destinations,
 	}
 }
 
-func (s *Shortener) CutLink(ctx context.Context, url string) (*modellink.Link, error) {
-	alias, err := s.linkDataProvider.GetAlias(ctx, url)
+// CutLink returns the short link for link.URL. The URL is validated and
+// normalized first. When link.Alias is empty one is generated, and the flag
+// is false if the URL had already been shortened and the existing alias is
+// reused. A non-empty alias is always created.
+func (s *Shortener) CutLink(ctx context.Context, link modellink.Link) (_ *modellink.Link, _ bool, err error) {
+	ctx, span := tracer.Start(ctx, "Shortener.CutLink")
+	defer func() { tracing.End(span, err) }()
+
+	link.URL, err = s.prepareURL(ctx, link.URL)
 	if err != nil {
-		return nil, fmt.Errorf(
+		return nil, false, err
+	}
+
+	if link.Alias != "" {
+		span.SetAttributes(tracing.AliasKey.String(link.Alias))
+
+		if err := s.linkDataProvider.CreateAlias(ctx, link); err != nil {
+			return nil, false, fmt.Errorf(
+				"s.linkDataProvider.CreateAlias: %w", err,
+			)
+		}
+
+		return &link, true, nil
+	}
+
+	stored, created, err := s.linkDataProvider.GetAlias(ctx, link)
+	if err != nil {
+		return nil, false, fmt.Errorf(
 			"s.linkDataProvider.GetAlias: %w", err,
 		)
 	}
 
-	link := &modellink.Link{
-		Alias: alias,
-		URL:   url,
+	span.SetAttributes(tracing.AliasKey.String(stored.Alias))
+
+	return stored, created, nil
+}
+
+// CutLinks shortens a batch of links like CutLink. A link that fails
+// validation only fails its own result, the returned error means the whole
+// batch failed.
+func (s *Shortener) CutLinks(ctx context.Context, links []modellink.Link) (_ []modellink.BatchResult, err error) {
+	ctx, span := tracer.Start(ctx, "Shortener.CutLinks")
+	defer func() { tracing.End(span, err) }()
+
+	links = slices.Clone(links)
+	results := make([]modellink.BatchResult, len(links))
+
+	var group errgroup.Group
+	group.SetLimit(batchChecks)
+	for i := range links {
+		group.Go(func() error {
+			links[i].URL, results[i].Err = s.prepareURL(ctx, links[i].URL)
+			return nil
+		})
+	}
+	group.Wait()
+
+	var (
+		valid   []modellink.Link
+		indexes []int
+	)
+	for i, link := range links {
+		if results[i].Err == nil {
+			valid = append(valid, link)
+			indexes = append(indexes, i)
+		}
+	}
+
+	if len(valid) == 0 {
+		return results, nil
+	}
+
+	stored, err := s.linkDataProvider.GetAliases(ctx, valid)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"s.linkDataProvider.GetAliases: %w", err,
+		)
+	}
+
+	for k, i := range indexes {
+		results[i] = stored[k]
+	}
+
+	return results, nil
+}
+
+// UpdateLink points an existing alias to a new URL, validated and
+// normalized like in CutLink. The previous URL is kept as a revision.
+func (s *Shortener) UpdateLink(ctx context.Context, alias, url string) (_ *modellink.Link, err error) {
+	ctx, span := tracer.Start(ctx, "Shortener.UpdateLink")
+	span.SetAttributes(tracing.AliasKey.String(alias))
+	defer func() { tracing.End(span, err) }()
+
+	url, err = s.prepareURL(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := s.linkDataProvider.UpdateAlias(ctx, alias, url)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"s.linkDataProvider.UpdateAlias: %w", err,
+		)
 	}
 
 	return link, nil
 }
 
-func (s *Shortener) GetFullLink(ctx context.Context, alias string) (*modellink.Link, error) {
-	url, err := s.linkDataProvider.GetURL(ctx, alias)
+// LinkRevisions returns the URLs alias pointed to before, oldest first.
+func (s *Shortener) LinkRevisions(ctx context.Context, alias string) (_ []models.Revision, err error) {
+	ctx, span := tracer.Start(ctx, "Shortener.LinkRevisions")
+	span.SetAttributes(tracing.AliasKey.String(alias))
+	defer func() { tracing.End(span, err) }()
+
+	revisions, err := s.linkDataProvider.GetRevisions(ctx, alias)
 	if err != nil {
 		return nil, fmt.Errorf(
-			"s.linkDataProvider.GetUrl: %w", err,
+			"s.linkDataProvider.GetRevisions: %w", err,
 		)
 	}
 
-	link := &modellink.Link{
-		Alias: alias,
-		URL:   url,
+	return revisions, nil
+}
+
+// ListLinks returns a page of links matching filter.
+func (s *Shortener) ListLinks(ctx context.Context, filter modellink.Filter) (_ *modellink.Page, err error) {
+	ctx, span := tracer.Start(ctx, "Shortener.ListLinks")
+	defer func() { tracing.End(span, err) }()
+
+	page, err := s.linkDataProvider.ListLinks(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"s.linkDataProvider.ListLinks: %w", err,
+		)
+	}
+
+	return page, nil
+}
+
+func (s *Shortener) GetFullLink(ctx context.Context, alias string) (_ *modellink.Link, err error) {
+	ctx, span := tracer.Start(ctx, "Shortener.GetFullLink")
+	span.SetAttributes(tracing.AliasKey.String(alias))
+	defer func() { tracing.End(span, err) }()
+
+	link, err := s.linkDataProvider.GetLink(ctx, alias)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"s.linkDataProvider.GetLink: %w", err,
+		)
+	}
+
+	return link, nil
+}
+
+// OwnedLink returns the link behind alias to its owner or an admin, expired
+// and disabled ones included.
+func (s *Shortener) OwnedLink(ctx context.Context, alias string) (_ *modellink.Link, err error) {
+	ctx, span := tracer.Start(ctx, "Shortener.OwnedLink")
+	span.SetAttributes(tracing.AliasKey.String(alias))
+	defer func() { tracing.End(span, err) }()
+
+	link, err := s.linkDataProvider.GetOwnedLink(ctx, alias)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"s.linkDataProvider.GetOwnedLink: %w", err,
+		)
 	}
 
 	return link, nil
 }
+
+func (s *Shortener) DeleteLink(ctx context.Context, alias string) (err error) {
+	ctx, span := tracer.Start(ctx, "Shortener.DeleteLink")
+	span.SetAttributes(tracing.AliasKey.String(alias))
+	defer func() { tracing.End(span, err) }()
+
+	err = s.linkDataProvider.DeleteAlias(ctx, alias)
+	if err != nil {
+		return fmt.Errorf(
+			"s.linkDataProvider.DeleteAlias: %w", err,
+		)
+	}
+
+	return nil
+}
+
+// SetLinkDisabled disables or re-enables the link behind alias. A disabled
+// link no longer resolves, but its alias stays taken.
+func (s *Shortener) SetLinkDisabled(ctx context.Context, alias string, disabled bool) (err error) {
+	ctx, span := tracer.Start(ctx, "Shortener.SetLinkDisabled")
+	span.SetAttributes(tracing.AliasKey.String(alias))
+	defer func() { tracing.End(span, err) }()
+
+	err = s.linkDataProvider.SetAliasDisabled(ctx, alias, disabled)
+	if err != nil {
+		return fmt.Errorf(
+			"s.linkDataProvider.SetAliasDisabled: %w", err,
+		)
+	}
+
+	return nil
+}
+
+// prepareURL normalizes raw and checks that it may be shortened.
+func (s *Shortener) prepareURL(ctx context.Context, raw string) (string, error) {
+	url, err := NormalizeURL(raw)
+	if err != nil {
+		return "", err
+	}
+
+	if s.destinations != nil {
+		if err := s.destinations.Check(ctx, url); err != nil {
+			return "", fmt.Errorf(
+				"s.destinations.Check: %w", err,
+			)
+		}
+	}
+
+	return url, nil
+}
@@ -0,0 +1,192 @@
+package link
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+type dataProviderMock struct {
+	getAliasInput    modellink.Link
+	createAliasInput modellink.Link
+	updateAliasURL   string
+	getAliasesInput  []modellink.Link
+	calls            int
+}
+
+func (m *dataProviderMock) GetAlias(ctx context.Context, link modellink.Link) (*modellink.Link, bool, error) {
+	m.calls++
+	m.getAliasInput = link
+	link.Alias = "generated"
+	return &link, true, nil
+}
+
+func (m *dataProviderMock) CreateAlias(ctx context.Context, link modellink.Link) error {
+	m.calls++
+	m.createAliasInput = link
+	return nil
+}
+
+func (m *dataProviderMock) GetAliases(ctx context.Context, links []modellink.Link) ([]modellink.BatchResult, error) {
+	m.calls++
+	m.getAliasesInput = links
+	results := make([]modellink.BatchResult, len(links))
+	for i, link := range links {
+		link.Alias = "generated"
+		results[i] = modellink.BatchResult{Link: &link, Created: true}
+	}
+	return results, nil
+}
+
+func (m *dataProviderMock) GetLink(ctx context.Context, alias string) (*modellink.Link, error) {
+	return nil, models.ErrNotFound
+}
+
+func (m *dataProviderMock) GetOwnedLink(ctx context.Context, alias string) (*modellink.Link, error) {
+	return nil, models.ErrNotFound
+}
+
+func (m *dataProviderMock) DeleteAlias(ctx context.Context, alias string) error {
+	return nil
+}
+
+func (m *dataProviderMock) SetAliasDisabled(ctx context.Context, alias string, disabled bool) error {
+	return nil
+}
+
+func (m *dataProviderMock) UpdateAlias(ctx context.Context, alias, url string) (*modellink.Link, error) {
+	m.calls++
+	m.updateAliasURL = url
+	return &modellink.Link{URL: url, Alias: alias}, nil
+}
+
+func (m *dataProviderMock) GetRevisions(ctx context.Context, alias string) ([]models.Revision, error) {
+	return nil, nil
+}
+
+func (m *dataProviderMock) ListLinks(ctx context.Context, filter modellink.Filter) (*modellink.Page, error) {
+	return &modellink.Page{}, nil
+}
+
+func TestShortener_CutLink_Normalizes(t *testing.T) {
+	provider := &dataProviderMock{}
+	s := NewShortener(provider, nil)
+
+	link, _, err := s.CutLink(context.Background(), modellink.Link{URL: "HTTPS://Example.com:443?utm_source=x&b=2&a=1"})
+	if err != nil {
+		t.Fatalf("CutLink() unexpected error: %v", err)
+	}
+
+	want := "https://example.com/?a=1&b=2"
+	if provider.getAliasInput.URL != want || link.URL != want {
+		t.Errorf("expected normalized URL %q, got %q", want, provider.getAliasInput.URL)
+	}
+
+	if _, _, err := s.CutLink(context.Background(), modellink.Link{URL: "https://Example.com", Alias: "promo"}); err != nil {
+		t.Fatalf("CutLink() with alias unexpected error: %v", err)
+	}
+	if provider.createAliasInput.URL != "https://example.com/" {
+		t.Errorf("expected normalized URL for custom alias, got %q", provider.createAliasInput.URL)
+	}
+}
+
+func TestShortener_CutLink_Invalid(t *testing.T) {
+	provider := &dataProviderMock{}
+	s := NewShortener(provider, nil)
+
+	_, _, err := s.CutLink(context.Background(), modellink.Link{URL: "not a url"})
+	if !errors.Is(err, models.ErrValidation) {
+		t.Fatalf("expected %v, got %v", models.ErrValidation, err)
+	}
+	if provider.calls != 0 {
+		t.Errorf("expected no data provider calls, got %d", provider.calls)
+	}
+}
+
+type checkerMock struct {
+	url string
+	err error
+}
+
+func (m *checkerMock) Check(ctx context.Context, url string) error {
+	m.url = url
+	return m.err
+}
+
+func TestShortener_CutLink_UnsafeDestination(t *testing.T) {
+	provider := &dataProviderMock{}
+	checker := &checkerMock{err: &models.DestinationError{Reason: "private_address", Host: "10.0.0.1"}}
+	s := NewShortener(provider, checker)
+
+	_, _, err := s.CutLink(context.Background(), modellink.Link{URL: "http://10.0.0.1:80/admin"})
+	if !errors.Is(err, models.ErrUnsafeDestination) {
+		t.Fatalf("expected %v, got %v", models.ErrUnsafeDestination, err)
+	}
+	if checker.url != "http://10.0.0.1/admin" {
+		t.Errorf("expected the normalized URL to be checked, got %q", checker.url)
+	}
+	if provider.calls != 0 {
+		t.Errorf("expected no data provider calls, got %d", provider.calls)
+	}
+}
+
+func TestShortener_UpdateLink(t *testing.T) {
+	provider := &dataProviderMock{}
+	checker := &checkerMock{}
+	s := NewShortener(provider, checker)
+
+	link, err := s.UpdateLink(context.Background(), "promo", "HTTPS://Example.com/new?utm_medium=x")
+	if err != nil {
+		t.Fatalf("UpdateLink() unexpected error: %v", err)
+	}
+
+	want := "https://example.com/new"
+	if provider.updateAliasURL != want || link.URL != want || checker.url != want {
+		t.Errorf("expected normalized URL %q, got %q", want, provider.updateAliasURL)
+	}
+
+	checker.err = &models.DestinationError{Reason: "denied_domain", Host: "evil.example"}
+	provider.calls = 0
+
+	if _, err := s.UpdateLink(context.Background(), "promo", "https://evil.example"); !errors.Is(err, models.ErrUnsafeDestination) {
+		t.Fatalf("expected %v, got %v", models.ErrUnsafeDestination, err)
+	}
+	if provider.calls != 0 {
+		t.Errorf("expected no data provider calls, got %d", provider.calls)
+	}
+}
+
+func TestShortener_CutLinks(t *testing.T) {
+	provider := &dataProviderMock{}
+	checker := &checkerMock{}
+	s := NewShortener(provider, checker)
+
+	results, err := s.CutLinks(context.Background(), []modellink.Link{
+		{URL: "not a url"},
+		{URL: "HTTPS://Example.com?utm_source=x"},
+	})
+	if err != nil {
+		t.Fatalf("CutLinks() unexpected error: %v", err)
+	}
+
+	if !errors.Is(results[0].Err, models.ErrValidation) {
+		t.Errorf("expected %v for the first link, got %v", models.ErrValidation, results[0].Err)
+	}
+	if results[1].Err != nil || results[1].Link.URL != "https://example.com/" {
+		t.Errorf("expected the second link to be normalized and stored, got %+v", results[1])
+	}
+	if len(provider.getAliasesInput) != 1 {
+		t.Errorf("expected only the valid link to be stored, got %+v", provider.getAliasesInput)
+	}
+
+	provider.calls = 0
+	if _, err := s.CutLinks(context.Background(), []modellink.Link{{URL: "not a url"}}); err != nil {
+		t.Fatalf("CutLinks() unexpected error: %v", err)
+	}
+	if provider.calls != 0 {
+		t.Errorf("expected no data provider calls, got %d", provider.calls)
+	}
+}
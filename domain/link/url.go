@@ -0,0 +1,117 @@
+package link
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+	"golang.org/x/net/idna"
+)
+
+// MaxURLLength bounds stored URLs, longer ones are rejected rather than
+// truncated.
+const MaxURLLength = 2048
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// NormalizeURL validates raw and returns its canonical form so equivalent
+// URLs share one alias: the scheme and host are lowercased, the host is
+// converted to punycode, default ports are dropped, query parameters are
+// sorted and utm_* tracking parameters removed. URLs with userinfo are
+// rejected.
+func NormalizeURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("%w: url is required", models.ErrValidation)
+	}
+	if len(raw) > MaxURLLength {
+		return "", fmt.Errorf("%w: url is longer than %d characters", models.ErrValidation, MaxURLLength)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("%w: malformed url", models.ErrValidation)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	defaultPort, ok := defaultPorts[u.Scheme]
+	if !ok || u.Opaque != "" {
+		return "", fmt.Errorf("%w: url must be an absolute http or https url", models.ErrValidation)
+	}
+	// https://trusted.example@evil.example/ reads as trusted.example, and
+	// credentials have no place in a public link
+	if u.User != nil {
+		return "", fmt.Errorf("%w: url must not contain credentials", models.ErrValidation)
+	}
+
+	host, err := normalizeHost(u.Hostname())
+	if err != nil {
+		return "", err
+	}
+
+	port := u.Port()
+	if port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return "", fmt.Errorf("%w: invalid port %q", models.ErrValidation, port)
+		}
+	}
+
+	switch {
+	case port != "" && port != defaultPort:
+		u.Host = net.JoinHostPort(host, port)
+	case strings.Contains(host, ":"):
+		u.Host = "[" + host + "]"
+	default:
+		u.Host = host
+	}
+
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return "", fmt.Errorf("%w: malformed query", models.ErrValidation)
+	}
+	for key := range query {
+		if strings.HasPrefix(strings.ToLower(key), "utm_") {
+			query.Del(key)
+		}
+	}
+	// Encode sorts by key
+	u.RawQuery = query.Encode()
+	u.ForceQuery = false
+
+	normalized := u.String()
+	if len(normalized) > MaxURLLength {
+		return "", fmt.Errorf("%w: url is longer than %d characters", models.ErrValidation, MaxURLLength)
+	}
+
+	return normalized, nil
+}
+
+// normalizeHost lowercases host and converts internationalized names to
+// punycode, IP literals are returned in their canonical form.
+func normalizeHost(host string) (string, error) {
+	if host == "" {
+		return "", fmt.Errorf("%w: url has no host", models.ErrValidation)
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return addr.String(), nil
+	}
+
+	ascii, err := idna.Lookup.ToASCII(host)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid host %q", models.ErrValidation, host)
+	}
+
+	return strings.ToLower(ascii), nil
+}
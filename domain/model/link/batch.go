@@ -0,0 +1,10 @@
+package link
+
+// BatchResult is the outcome for one link of a batch, Err is set when that
+// link could not be shortened.
+type BatchResult struct {
+	Link *Link
+	// Created is false when an existing alias was reused.
+	Created bool
+	Err     error
+}
@@ -1,8 +1,32 @@
 package link
 
-import "context"
+import (
+	"context"
+
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
 
 type DataProvider interface {
-	GetAlias(ctx context.Context, url string) (string, error)
-	GetURL(ctx context.Context, alias string) (string, error)
+	// GetAlias returns the stored link for link.URL and reports whether it
+	// was created by this call or already stored.
+	GetAlias(ctx context.Context, link Link) (*Link, bool, error)
+	// CreateAlias stores link under the alias chosen by the client.
+	CreateAlias(ctx context.Context, link Link) error
+	// GetAliases handles every link like GetAlias, or like CreateAlias when
+	// its alias is set, and returns a result per link in the same order.
+	GetAliases(ctx context.Context, links []Link) ([]BatchResult, error)
+	GetLink(ctx context.Context, alias string) (*Link, error)
+	// GetOwnedLink returns the link behind alias as stored, expired and
+	// disabled ones included, if the caller owns it or holds an admin key.
+	GetOwnedLink(ctx context.Context, alias string) (*Link, error)
+	DeleteAlias(ctx context.Context, alias string) error
+	// SetAliasDisabled disables or re-enables the link behind alias.
+	SetAliasDisabled(ctx context.Context, alias string, disabled bool) error
+	// UpdateAlias points alias to url and keeps the previous URL as a
+	// revision.
+	UpdateAlias(ctx context.Context, alias, url string) (*Link, error)
+	// GetRevisions returns the previous URLs of alias, oldest first.
+	GetRevisions(ctx context.Context, alias string) ([]models.Revision, error)
+	// ListLinks returns a page of links matching filter.
+	ListLinks(ctx context.Context, filter Filter) (*Page, error)
 }
@@ -1,6 +1,21 @@
 package link
 
+import "time"
+
 type Link struct {
 	URL   string
 	Alias string
+	// ExpiresAt is nil for links that never expire.
+	ExpiresAt *time.Time
+	// Owner identifies the API key owner that created the link, it is
+	// empty for links created without authentication.
+	Owner string
+	// Disabled links stop resolving but keep their alias reserved.
+	Disabled  bool
+	CreatedAt time.Time
+}
+
+// Expired reports whether the link has expired at the moment now.
+func (l *Link) Expired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
 }
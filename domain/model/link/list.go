@@ -0,0 +1,36 @@
+package link
+
+import "time"
+
+// Filter selects links for a listing, zero fields match every link.
+type Filter struct {
+	Owner string
+	// CreatedAfter is inclusive, CreatedBefore is exclusive.
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// URLContains matches a substring of the normalized URL.
+	URLContains string
+	// Domain matches the URL host and its subdomains.
+	Domain string
+
+	// Ascending sorts oldest first, the default is newest first.
+	Ascending bool
+	Limit     int
+	// After continues a listing past the last link of a previous page.
+	After *Cursor
+}
+
+// Cursor is the position of a link in a listing sorted by creation time.
+// ID orders links created at the same moment in SQL storages, Alias does
+// the same elsewhere.
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        int64     `json:"i,omitempty"`
+	Alias     string    `json:"a,omitempty"`
+}
+
+type Page struct {
+	Links []Link
+	// Next is nil on the last page.
+	Next *Cursor
+}
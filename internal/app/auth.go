@@ -0,0 +1,82 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/broadcast80/ozon-task/internal/pkg/auth"
+	"github.com/broadcast80/ozon-task/internal/pkg/logging"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+const apiKeyHeader = "X-API-Key"
+
+// KeyStore resolves the SHA-256 hash of an API key to its principal and
+// returns models.ErrNotFound for unknown or revoked keys.
+type KeyStore interface {
+	LookupKey(ctx context.Context, hash string) (*models.Principal, error)
+}
+
+// SetKeyStore enables API key authentication. It must be called before
+// MapHandlers, without a store every request is anonymous.
+func (h *handlers) SetKeyStore(keys KeyStore) {
+	h.keys = keys
+}
+
+// authenticate resolves the API key of the request, if any, into the
+// principal stored in the request context. A key that does not resolve is
+// always rejected, a missing one only when required is set. Lookups are
+// throttled by client IP, see SetAuthLimiter.
+func (h *handlers) authenticate(required bool, next http.HandlerFunc) http.HandlerFunc {
+	if h.keys == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := requestKey(r)
+		if key == "" {
+			if required {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				h.writeError(w, r, models.ErrUnauthorized)
+				return
+			}
+			next(w, r)
+			return
+		}
+
+		if h.authLimiter != nil && !h.allow(w, r, h.authLimiter, "ip:"+h.clientIP(r), 1) {
+			return
+		}
+
+		principal, err := h.keys.LookupKey(r.Context(), auth.HashKey(key))
+		if errors.Is(err, models.ErrNotFound) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			h.writeError(w, r, models.ErrUnauthorized)
+			return
+		} else if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+
+		ctx := auth.WithPrincipal(r.Context(), *principal)
+		ctx = logging.With(ctx, "owner", principal.Owner)
+
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// requestKey reads the key from X-API-Key or a bearer Authorization header.
+func requestKey(r *http.Request) string {
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		return key
+	}
+
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+
+	return ""
+}
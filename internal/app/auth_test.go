@@ -0,0 +1,217 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/auth"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+	"github.com/broadcast80/ozon-task/internal/pkg/ratelimit"
+)
+
+type mockKeyStore map[string]models.Principal
+
+func (m mockKeyStore) LookupKey(ctx context.Context, hash string) (*models.Principal, error) {
+	principal, ok := m[hash]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+	return &principal, nil
+}
+
+var testKeys = mockKeyStore{
+	auth.HashKey("grace-key"): {Owner: "grace"},
+}
+
+func newAuthHandlers(t *testing.T, service *mockShortener) *handlers {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := New(http.NewServeMux(), service, &mockAnalytics{}, logger, http.StatusFound)
+	h.SetKeyStore(testKeys)
+	if err := h.MapHandlers(); err != nil {
+		t.Fatalf("failed to map handlers: %v", err)
+	}
+	return h
+}
+
+func TestHandlers_Auth_Create(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		value      string
+		wantStatus int
+		wantOwner  string
+	}{
+		{name: "missing_key", wantStatus: http.StatusUnauthorized},
+		{name: "unknown_key", header: apiKeyHeader, value: "nope", wantStatus: http.StatusUnauthorized},
+		{name: "api_key_header", header: apiKeyHeader, value: "grace-key", wantStatus: http.StatusCreated, wantOwner: "grace"},
+		{name: "bearer", header: "Authorization", value: "Bearer grace-key", wantStatus: http.StatusCreated, wantOwner: "grace"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockShortener{
+				cutLinkResult:  &modellink.Link{Alias: "abc", URL: "https://example.com", Owner: "grace"},
+				cutLinkCreated: true,
+			}
+			h := newAuthHandlers(t, mockService)
+
+			req, _ := http.NewRequest("POST", "/api/v1/links", bytes.NewBufferString(`{"url": "https://example.com"}`))
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+
+			rr := httptest.NewRecorder()
+			h.Handler().ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, rr.Code)
+			}
+
+			if tt.wantStatus == http.StatusUnauthorized {
+				if body := decodeError(t, rr); body.Code != codeUnauthorized {
+					t.Errorf("expected code %s, got %s", codeUnauthorized, body.Code)
+				}
+				if mockService.cutLinkCalled {
+					t.Error("expected CutLink not to be called")
+				}
+				return
+			}
+
+			if mockService.cutLinkOwner != tt.wantOwner {
+				t.Errorf("expected owner %q, got %q", tt.wantOwner, mockService.cutLinkOwner)
+			}
+		})
+	}
+}
+
+func TestHandlers_Auth_PublicRoutes(t *testing.T) {
+	mockService := &mockShortener{
+		getFullLinkResult: &modellink.Link{Alias: "abc", URL: "https://example.com"},
+	}
+	h := newAuthHandlers(t, mockService)
+
+	for _, path := range []string{"/abc", "/api/v1/links/abc"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		h.Handler().ServeHTTP(rr, req)
+
+		if rr.Code == http.StatusUnauthorized {
+			t.Errorf("%s: expected anonymous access, got 401", path)
+		}
+	}
+}
+
+func TestHandlers_Auth_DeleteForbidden(t *testing.T) {
+	mockService := &mockShortener{deleteLinkErr: models.ErrForbidden}
+	h := newAuthHandlers(t, mockService)
+
+	req, _ := http.NewRequest("DELETE", "/api/v1/links/abc", nil)
+	rr := httptest.NewRecorder()
+	h.Handler().ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnauthorized || mockService.deleteLinkCalled {
+		t.Fatalf("expected 401 without key, got %d", rr.Code)
+	}
+
+	req, _ = http.NewRequest("DELETE", "/api/v1/links/abc", nil)
+	req.Header.Set(apiKeyHeader, "grace-key")
+	rr = httptest.NewRecorder()
+	h.Handler().ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rr.Code)
+	}
+	if body := decodeError(t, rr); body.Code != codeForbidden {
+		t.Errorf("expected code %s, got %s", codeForbidden, body.Code)
+	}
+}
+
+func TestHandlers_Auth_RateLimitByOwner(t *testing.T) {
+	mockService := &mockShortener{
+		cutLinkResult: &modellink.Link{Alias: "abc", URL: "https://example.com"},
+	}
+	limiter := &mockLimiter{decision: ratelimit.Decision{Allowed: true}}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := New(http.NewServeMux(), mockService, &mockAnalytics{}, logger, http.StatusFound)
+	h.SetKeyStore(testKeys)
+	h.SetRateLimiters(limiter, nil)
+	h.MapHandlers()
+
+	req, _ := http.NewRequest("POST", "/api/v1/links", bytes.NewBufferString(`{"url": "https://example.com"}`))
+	req.Header.Set(apiKeyHeader, "grace-key")
+	h.Handler().ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(limiter.keys) != 1 || limiter.keys[0] != "owner:grace" {
+		t.Errorf("expected limiter key owner:grace, got %v", limiter.keys)
+	}
+}
+
+// countingKeyStore counts lookups, which are queries for a real store.
+type countingKeyStore struct {
+	mockKeyStore
+	lookups int
+}
+
+func (c *countingKeyStore) LookupKey(ctx context.Context, hash string) (*models.Principal, error) {
+	c.lookups++
+	return c.mockKeyStore.LookupKey(ctx, hash)
+}
+
+func TestHandlers_Auth_LookupThrottled(t *testing.T) {
+	keys := &countingKeyStore{mockKeyStore: testKeys}
+	limiter := &mockLimiter{decision: ratelimit.Decision{Limit: 1, RetryAfter: time.Second}}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := New(http.NewServeMux(), &mockShortener{}, &mockAnalytics{}, logger, http.StatusFound)
+	h.SetKeyStore(keys)
+	h.SetAuthLimiter(limiter)
+	h.MapHandlers()
+
+	req, _ := http.NewRequest("DELETE", "/api/v1/links/abc", nil)
+	req.RemoteAddr = "203.0.113.7:4242"
+	req.Header.Set(apiKeyHeader, "guessed-key")
+	rr := httptest.NewRecorder()
+	h.Handler().ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rr.Code)
+	}
+	if keys.lookups != 0 {
+		t.Errorf("expected no key lookup, got %d", keys.lookups)
+	}
+	if len(limiter.keys) != 1 || limiter.keys[0] != "ip:203.0.113.7" {
+		t.Errorf("expected limiter key ip:203.0.113.7, got %v", limiter.keys)
+	}
+}
+
+func TestHandlers_Auth_StatsOwnerOnly(t *testing.T) {
+	mockService := &mockShortener{ownedLinkErr: models.ErrForbidden}
+	h := newAuthHandlers(t, mockService)
+
+	req, _ := http.NewRequest("GET", "/api/v1/links/abc/stats", nil)
+	rr := httptest.NewRecorder()
+	h.Handler().ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnauthorized || mockService.ownedLinkInput != "" {
+		t.Fatalf("expected 401 without key, got %d", rr.Code)
+	}
+
+	req, _ = http.NewRequest("GET", "/api/v1/links/abc/stats", nil)
+	req.Header.Set(apiKeyHeader, "grace-key")
+	rr = httptest.NewRecorder()
+	h.Handler().ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,141 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+const defaultMaxBatchSize = 1000
+
+// SetMaxBatchSize caps the number of items accepted by CreateBatch.
+func (h *handlers) SetMaxBatchSize(size int) {
+	if size > 0 {
+		h.maxBatchSize = size
+	}
+}
+
+// CreateBatch shortens a JSON array of create requests, or an NDJSON stream
+// of them when sent as application/x-ndjson. Every item gets its own status
+// and error, the batch as a whole fails only if it cannot be read or stored.
+// The batch limiter is charged once the items are counted.
+func (h *handlers) CreateBatch(w http.ResponseWriter, r *http.Request) {
+	requests, err := h.decodeBatch(w, r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	if h.batchLimiter != nil && !h.allow(w, r, h.batchLimiter, h.rateLimitKey(r), len(requests)) {
+		return
+	}
+
+	now := time.Now()
+	results := make([]models.BatchItem, len(requests))
+
+	var (
+		links   []modellink.Link
+		indexes []int
+	)
+	for i, request := range requests {
+		link, err := newLink(r, request, now)
+		if err != nil {
+			results[i] = h.batchError(r, err)
+			continue
+		}
+		links = append(links, link)
+		indexes = append(indexes, i)
+	}
+
+	if len(links) > 0 {
+		stored, err := h.service.CutLinks(r.Context(), links)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+
+		for k, i := range indexes {
+			result := stored[k]
+			if result.Err != nil {
+				results[i] = h.batchError(r, result.Err)
+				continue
+			}
+
+			status := http.StatusOK
+			if result.Created {
+				status = http.StatusCreated
+			}
+			response := newResponse(result.Link)
+			results[i] = models.BatchItem{Status: status, Link: &response}
+		}
+	}
+
+	h.writeJSON(w, http.StatusOK, models.BatchResponse{Results: results})
+}
+
+func (h *handlers) batchError(r *http.Request, err error) models.BatchItem {
+	status, body := h.errorBody(r, err)
+	return models.BatchItem{Status: status, Error: &body}
+}
+
+// decodeBatch reads the items one by one and stops as soon as there are
+// more than allowed, without buffering the rest of the body. The body is
+// capped at maxBodySize per allowed item.
+func (h *handlers) decodeBatch(w http.ResponseWriter, r *http.Request) ([]models.Request, error) {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, int64(h.maxBatchSize)*maxBodySize))
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	ndjson := mediaType == "application/x-ndjson"
+
+	if !ndjson {
+		token, err := decoder.Token()
+		if tooLarge := tooLarge(err); tooLarge != nil {
+			return nil, tooLarge
+		}
+		if err != nil || token != json.Delim('[') {
+			return nil, fmt.Errorf("%w: request must be a JSON array", models.ErrValidation)
+		}
+	}
+
+	var requests []models.Request
+	for decoder.More() {
+		if len(requests) == h.maxBatchSize {
+			return nil, fmt.Errorf("%w: batch exceeds %d items", models.ErrValidation, h.maxBatchSize)
+		}
+
+		var request models.Request
+		if err := decoder.Decode(&request); err != nil {
+			if tooLarge := tooLarge(err); tooLarge != nil {
+				return nil, tooLarge
+			}
+			return nil, fmt.Errorf("%w: failed to unmarshal item %d", models.ErrValidation, len(requests))
+		}
+		requests = append(requests, request)
+	}
+
+	// the closing bracket of an array, the end of the body for NDJSON
+	token, err := decoder.Token()
+	if tooLarge := tooLarge(err); tooLarge != nil {
+		return nil, tooLarge
+	}
+	complete := errors.Is(err, io.EOF)
+	if !ndjson {
+		complete = err == nil && token == json.Delim(']')
+	}
+	if !complete {
+		return nil, fmt.Errorf("%w: failed to unmarshal item %d", models.ErrValidation, len(requests))
+	}
+
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("%w: batch is empty", models.ErrValidation)
+	}
+
+	return requests, nil
+}
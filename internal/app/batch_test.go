@@ -0,0 +1,179 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+func TestHandlers_CreateBatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{
+			name:        "json_array",
+			contentType: "application/json",
+			body:        `[{"url":"https://a.com"},{"url":""},{"url":"https://b.com","alias":"promo"}]`,
+		},
+		{
+			name:        "ndjson",
+			contentType: "application/x-ndjson; charset=utf-8",
+			body:        "{\"url\":\"https://a.com\"}\n{\"url\":\"\"}\n{\"url\":\"https://b.com\",\"alias\":\"promo\"}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockShortener{
+				cutLinksResult: []modellink.BatchResult{
+					{Link: &modellink.Link{URL: "https://a.com", Alias: "abc"}},
+					{Err: models.ErrAliasTaken},
+				},
+			}
+
+			router := http.NewServeMux()
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+			h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+			h.MapHandlers()
+
+			req, _ := http.NewRequest("POST", "/api/v1/links:batch", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+
+			if len(mockService.cutLinksInput) != 2 || mockService.cutLinksInput[1].Alias != "promo" {
+				t.Errorf("expected only the valid items to be shortened, got %+v", mockService.cutLinksInput)
+			}
+
+			var response models.BatchResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			if len(response.Results) != 3 {
+				t.Fatalf("expected 3 results, got %d", len(response.Results))
+			}
+
+			first := response.Results[0]
+			if first.Status != http.StatusOK || first.Link == nil || first.Link.Alias != "abc" {
+				t.Errorf("unexpected first result %+v", first)
+			}
+
+			second := response.Results[1]
+			if second.Status != http.StatusBadRequest || second.Error == nil || second.Error.Code != codeValidation {
+				t.Errorf("unexpected second result %+v", second)
+			}
+
+			third := response.Results[2]
+			if third.Status != http.StatusConflict || third.Error == nil || third.Error.Code != codeAliasTaken {
+				t.Errorf("unexpected third result %+v", third)
+			}
+		})
+	}
+}
+
+func TestHandlers_CreateBatch_Rejected(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{name: "not_an_array", contentType: "application/json", body: `{"url":"https://a.com"}`},
+		{name: "empty", contentType: "application/json", body: `[]`},
+		{name: "empty_ndjson", contentType: "application/x-ndjson", body: ``},
+		{name: "invalid_item", contentType: "application/json", body: `[{"url":"https://a.com"},{"url":1}]`},
+		{name: "unterminated", contentType: "application/json", body: `[{"url":"https://a.com"}`},
+		{name: "too_large", contentType: "application/json", body: `[{"url":"https://a.com"},{"url":"https://b.com"},{"url":"https://c.com"}]`},
+		{name: "too_large_ndjson", contentType: "application/x-ndjson", body: "{\"url\":\"https://a.com\"}\n{\"url\":\"https://b.com\"}\n{\"url\":\"https://c.com\"}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockShortener{}
+
+			router := http.NewServeMux()
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+			h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+			h.SetMaxBatchSize(2)
+			h.MapHandlers()
+
+			req, _ := http.NewRequest("POST", "/api/v1/links:batch", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+			}
+			if mockService.cutLinksInput != nil {
+				t.Errorf("expected no links to be shortened, got %+v", mockService.cutLinksInput)
+			}
+		})
+	}
+}
+
+func TestHandlers_CreateBatch_BodyTooLarge(t *testing.T) {
+	mockService := &mockShortener{}
+
+	router := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+	h.SetMaxBatchSize(2)
+	h.MapHandlers()
+
+	// a single item may not use up the budget of the whole batch
+	body := `[{"url":"https://a.com/` + strings.Repeat("a", 2*maxBodySize) + `"}]`
+	req, _ := http.NewRequest("POST", "/api/v1/links:batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if body := decodeError(t, rr); body.Code != codeTooLarge {
+		t.Errorf("expected code %s, got %s", codeTooLarge, body.Code)
+	}
+	if mockService.cutLinksInput != nil {
+		t.Errorf("expected no links to be shortened, got %+v", mockService.cutLinksInput)
+	}
+}
+
+func TestHandlers_CreateBatch_ServiceError(t *testing.T) {
+	mockService := &mockShortener{cutLinksErr: errors.New("db down")}
+
+	router := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+	h.MapHandlers()
+
+	req, _ := http.NewRequest("POST", "/api/v1/links:batch", strings.NewReader(`[{"url":"https://a.com"}]`))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
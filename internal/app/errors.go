@@ -0,0 +1,98 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/broadcast80/ozon-task/internal/pkg/logging"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+const (
+	codeValidation   = "validation_failed"
+	codeNotFound     = "not_found"
+	codeDuplicate    = "duplicate"
+	codeAliasTaken   = "alias_taken"
+	codeExpired      = "expired"
+	codeDisabled     = "disabled"
+	codeStorageFull  = "storage_full"
+	codeRateLimited  = "rate_limited"
+	codeUnauthorized = "unauthorized"
+	codeForbidden    = "forbidden"
+	codeUnsafeURL    = "unsafe_destination"
+	codeTooLarge     = "too_large"
+	codeInternal     = "internal_error"
+)
+
+// errorStatus maps sentinel errors from models to an HTTP status and a
+// machine-readable code. Unknown errors are reported as internal.
+func errorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, models.ErrValidation):
+		return http.StatusBadRequest, codeValidation
+	case errors.Is(err, models.ErrNotFound):
+		return http.StatusNotFound, codeNotFound
+	case errors.Is(err, models.ErrExpired):
+		return http.StatusGone, codeExpired
+	case errors.Is(err, models.ErrDisabled):
+		return http.StatusGone, codeDisabled
+	case errors.Is(err, models.ErrAliasTaken):
+		return http.StatusConflict, codeAliasTaken
+	case errors.Is(err, models.ErrDuplicate):
+		return http.StatusConflict, codeDuplicate
+	case errors.Is(err, models.ErrStorageFull):
+		return http.StatusInsufficientStorage, codeStorageFull
+	case errors.Is(err, models.ErrUnauthorized):
+		return http.StatusUnauthorized, codeUnauthorized
+	case errors.Is(err, models.ErrForbidden):
+		return http.StatusForbidden, codeForbidden
+	case errors.Is(err, models.ErrUnsafeDestination):
+		return http.StatusUnprocessableEntity, codeUnsafeURL
+	case errors.Is(err, models.ErrTooLarge):
+		return http.StatusRequestEntityTooLarge, codeTooLarge
+	case errors.Is(err, models.ErrRateLimited):
+		return http.StatusTooManyRequests, codeRateLimited
+	default:
+		return http.StatusInternalServerError, codeInternal
+	}
+}
+
+func (h *handlers) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status, body := h.errorBody(r, err)
+	h.writeJSON(w, status, models.ErrorResponse{Error: body})
+}
+
+// errorBody maps err to a status and an error body. Internal errors are
+// logged and their details are not shown to the client.
+func (h *handlers) errorBody(r *http.Request, err error) (int, models.ErrorBody) {
+	status, code := errorStatus(err)
+
+	message := err.Error()
+	if status == http.StatusInternalServerError {
+		logging.FromContext(r.Context(), h.logger).Error(message)
+		message = "internal server error"
+	}
+
+	body := models.ErrorBody{Code: code, Message: message}
+
+	var destinationErr *models.DestinationError
+	if errors.As(err, &destinationErr) {
+		body.Reason = destinationErr.Reason
+	}
+
+	return status, body
+}
+
+func (h *handlers) writeJSON(w http.ResponseWriter, status int, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		h.logger.Error(err.Error())
+		http.Error(w, "failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
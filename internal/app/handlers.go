@@ -3,106 +3,377 @@ package app
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"net/netip"
+	"sync/atomic"
+	"time"
 
+	domainlink "github.com/broadcast80/ozon-task/domain/link"
 	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/auth"
+	"github.com/broadcast80/ozon-task/internal/pkg/logging"
+	"github.com/broadcast80/ozon-task/internal/pkg/metrics"
 	"github.com/broadcast80/ozon-task/internal/pkg/models"
 )
 
 type handlers struct {
-	router  *http.ServeMux
-	service Shortener
-	logger  *slog.Logger
+	router         *http.ServeMux
+	service        Shortener
+	analytics      Analytics
+	logger         *slog.Logger
+	redirectStatus int
+
+	ready  atomic.Bool
+	checks []check
+
+	createLimiter  RateLimiter
+	resolveLimiter RateLimiter
+	batchLimiter   RateLimiter
+	authLimiter    RateLimiter
+	trustedProxies []netip.Prefix
+
+	keys KeyStore
+
+	maxBatchSize int
+
+	transfer Transfer
 }
 
 type Shortener interface {
-	CutLink(ctx context.Context, url string) (*modellink.Link, error)
+	CutLink(ctx context.Context, link modellink.Link) (*modellink.Link, bool, error)
+	CutLinks(ctx context.Context, links []modellink.Link) ([]modellink.BatchResult, error)
 	GetFullLink(ctx context.Context, alias string) (*modellink.Link, error)
+	OwnedLink(ctx context.Context, alias string) (*modellink.Link, error)
+	DeleteLink(ctx context.Context, alias string) error
+	SetLinkDisabled(ctx context.Context, alias string, disabled bool) error
+	UpdateLink(ctx context.Context, alias, url string) (*modellink.Link, error)
+	LinkRevisions(ctx context.Context, alias string) ([]models.Revision, error)
+	ListLinks(ctx context.Context, filter modellink.Filter) (*modellink.Page, error)
 }
 
-func New(router *http.ServeMux, service Shortener, logger *slog.Logger) *handlers {
+type Analytics interface {
+	Record(hit models.Hit, clientIP string)
+	Stats(ctx context.Context, alias string) (*models.Stats, error)
+}
+
+func New(router *http.ServeMux, service Shortener, analytics Analytics, logger *slog.Logger, redirectStatus int) *handlers {
 	return &handlers{
-		router:  router,
-		service: service,
-		logger:  logger,
+		router:         router,
+		service:        service,
+		analytics:      analytics,
+		logger:         logger,
+		redirectStatus: redirectStatus,
+		maxBatchSize:   defaultMaxBatchSize,
 	}
 }
 
-func (h *handlers) ListenAndServe(port string) error {
-	address := ":" + port
-	err := http.ListenAndServe(address, h.router)
-	if err != nil {
-		return fmt.Errorf("listen and serve error: %w", err)
-	}
-	return nil
+// Handler returns the router wrapped in the middleware chain, outermost
+// first: request ID and scoped logger, access log, tracing, metrics and
+// panic recovery.
+func (h *handlers) Handler() http.Handler {
+	return h.withRequestID(h.accessLog(traceRequests(instrument(h.recoverPanic(h.router)))))
 }
 
 func (h *handlers) MapHandlers() error {
-	h.router.HandleFunc("POST /", h.Create)
-	h.router.HandleFunc("GET /", h.Get)
+	switch h.redirectStatus {
+	case http.StatusMovedPermanently, http.StatusFound,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return fmt.Errorf("unsupported redirect status: %d", h.redirectStatus)
+	}
+
+	h.router.HandleFunc("POST /api/v1/links", h.authenticate(true, h.limit(h.createLimiter, h.Create)))
+	h.router.HandleFunc("GET /api/v1/links", h.authenticate(true, h.List))
+	h.router.HandleFunc("POST /api/v1/links:batch", h.authenticate(true, h.CreateBatch))
+	h.router.HandleFunc("GET /api/v1/links/{alias}", h.authenticate(false, h.limit(h.resolveLimiter, h.Get)))
+	h.router.HandleFunc("PATCH /api/v1/links/{alias}", h.authenticate(true, h.Update))
+	h.router.HandleFunc("DELETE /api/v1/links/{alias}", h.authenticate(true, h.Delete))
+	h.router.HandleFunc("POST /api/v1/links/{alias}/disable", h.authenticate(true, h.Disable))
+	h.router.HandleFunc("POST /api/v1/links/{alias}/enable", h.authenticate(true, h.Enable))
+	h.router.HandleFunc("GET /api/v1/links/{alias}/stats", h.authenticate(true, h.limit(h.resolveLimiter, h.Stats)))
+	h.router.HandleFunc("GET /api/v1/links/{alias}/revisions", h.authenticate(true, h.Revisions))
+	// without a key store every caller is anonymous, nobody can be an admin
+	if h.transfer != nil && h.keys != nil {
+		h.router.HandleFunc("GET /api/v1/admin/export", h.authenticate(true, h.Export))
+		h.router.HandleFunc("POST /api/v1/admin/import", h.authenticate(true, h.Import))
+	}
+	h.router.HandleFunc("GET /healthz", h.Liveness)
+	h.router.HandleFunc("GET /readyz", h.Readiness)
+	h.router.Handle("GET /metrics", metrics.Default.Handler())
+	h.router.HandleFunc("GET /{alias}", h.limit(h.resolveLimiter, h.Redirect))
 
 	return nil
 }
 
 func (h *handlers) Create(w http.ResponseWriter, r *http.Request) {
 
-	body, err := io.ReadAll(r.Body)
+	body, err := readBody(w, r)
 	if err != nil {
-		h.logger.Error(err.Error())
-		http.Error(w, "failed to read request", http.StatusBadRequest)
+		h.writeError(w, r, err)
+		return
 	}
 
 	var request models.Request
 
 	err = json.Unmarshal(body, &request)
 	if err != nil {
-		h.logger.Error(err.Error())
-		http.Error(w, "failed to unmarshal request", http.StatusBadRequest)
+		h.writeError(w, r, fmt.Errorf("%w: failed to unmarshal request", models.ErrValidation))
+		return
 	}
 
-	link, err := h.service.CutLink(r.Context(), request.URL)
+	requested, err := newLink(r, request, time.Now())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.writeError(w, r, err)
+		return
 	}
 
-	data, err := json.Marshal(link)
+	link, created, err := h.service.CutLink(r.Context(), requested)
 	if err != nil {
-		http.Error(w, "failed to marhall response", http.StatusInternalServerError)
+		h.writeError(w, r, err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(data)
+	h.writeJSON(w, status, newResponse(link))
 }
 
 func (h *handlers) Get(w http.ResponseWriter, r *http.Request) {
+	alias := r.PathValue("alias")
 
-	body, err := io.ReadAll(r.Body)
+	link, err := h.service.GetFullLink(r.Context(), alias)
 	if err != nil {
-		h.logger.Error(err.Error())
-		http.Error(w, "failed to read request", http.StatusBadRequest)
+		h.writeError(w, r, err)
+		return
 	}
 
-	var request models.Request
+	h.recordHit(r, link.Alias)
+
+	h.writeJSON(w, http.StatusOK, newResponse(link))
+}
+
+func (h *handlers) Update(w http.ResponseWriter, r *http.Request) {
+	alias := r.PathValue("alias")
+
+	body, err := readBody(w, r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	var request models.UpdateRequest
 
 	err = json.Unmarshal(body, &request)
 	if err != nil {
-		h.logger.Error(err.Error())
-		http.Error(w, "failed to unmarshal request", http.StatusBadRequest)
+		h.writeError(w, r, fmt.Errorf("%w: failed to unmarshal request", models.ErrValidation))
+		return
+	}
+
+	if request.URL == "" {
+		h.writeError(w, r, fmt.Errorf("%w: url is required", models.ErrValidation))
+		return
+	}
+
+	link, err := h.service.UpdateLink(r.Context(), alias, request.URL)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, newResponse(link))
+}
+
+func (h *handlers) Revisions(w http.ResponseWriter, r *http.Request) {
+	alias := r.PathValue("alias")
+
+	revisions, err := h.service.LinkRevisions(r.Context(), alias)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	if revisions == nil {
+		revisions = []models.Revision{}
+	}
+
+	h.writeJSON(w, http.StatusOK, models.RevisionsResponse{Alias: alias, Revisions: revisions})
+}
+
+func (h *handlers) Delete(w http.ResponseWriter, r *http.Request) {
+	alias := r.PathValue("alias")
+
+	if err := h.service.DeleteLink(r.Context(), alias); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handlers) Disable(w http.ResponseWriter, r *http.Request) {
+	h.setDisabled(w, r, true)
+}
+
+func (h *handlers) Enable(w http.ResponseWriter, r *http.Request) {
+	h.setDisabled(w, r, false)
+}
+
+func (h *handlers) setDisabled(w http.ResponseWriter, r *http.Request, disabled bool) {
+	alias := r.PathValue("alias")
+
+	if err := h.service.SetLinkDisabled(r.Context(), alias, disabled); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handlers) Redirect(w http.ResponseWriter, r *http.Request) {
+	alias := r.PathValue("alias")
+
+	link, err := h.service.GetFullLink(r.Context(), alias)
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if errors.Is(err, models.ErrExpired) {
+			http.Error(w, "link expired", http.StatusGone)
+			return
+		}
+		if errors.Is(err, models.ErrDisabled) {
+			http.Error(w, "link disabled", http.StatusGone)
+			return
+		}
+		logging.FromContext(r.Context(), h.logger).Error(err.Error())
+		http.Error(w, "failed to resolve link", http.StatusInternalServerError)
+		return
 	}
 
-	link, err := h.service.GetFullLink(r.Context(), request.Alias)
+	h.recordHit(r, link.Alias)
+
+	http.Redirect(w, r, link.URL, h.redirectStatus)
+}
+
+func (h *handlers) Stats(w http.ResponseWriter, r *http.Request) {
+	alias := r.PathValue("alias")
+
+	// stats of expired and disabled links are still available to the owner
+	if _, err := h.service.OwnedLink(r.Context(), alias); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	stats, err := h.analytics.Stats(r.Context(), alias)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.writeError(w, r, err)
+		return
 	}
 
-	data, err := json.Marshal(link)
+	h.writeJSON(w, http.StatusOK, stats)
+}
+
+func (h *handlers) recordHit(r *http.Request, alias string) {
+	h.analytics.Record(models.Hit{
+		Alias:     alias,
+		At:        time.Now(),
+		Referer:   r.Referer(),
+		UserAgent: r.UserAgent(),
+	}, h.clientIP(r))
+}
+
+// newLink builds the link described by a create request of the caller of r.
+func newLink(r *http.Request, request models.Request, now time.Time) (modellink.Link, error) {
+	if request.URL == "" {
+		return modellink.Link{}, fmt.Errorf("%w: url is required", models.ErrValidation)
+	}
+
+	expiresAt, err := requestExpiry(request, now)
 	if err != nil {
-		http.Error(w, "failed to marhall response", http.StatusInternalServerError)
+		return modellink.Link{}, err
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(data)
+	// anonymous links have no owner, they only exist while auth is disabled
+	principal, _ := auth.FromContext(r.Context())
+
+	return modellink.Link{
+		URL:       request.URL,
+		Alias:     request.Alias,
+		ExpiresAt: expiresAt,
+		Owner:     principal.Owner,
+		CreatedAt: now,
+	}, nil
+}
+
+// maxBodySize bounds the body of a single link request, enough for a URL
+// of the maximum length escaped in JSON and the other fields.
+const maxBodySize = 8 * domainlink.MaxURLLength
+
+// readBody reads the request body up to maxBodySize.
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		if tooLarge := tooLarge(err); tooLarge != nil {
+			return nil, tooLarge
+		}
+		return nil, fmt.Errorf("%w: failed to read request", models.ErrValidation)
+	}
+
+	return body, nil
+}
+
+// tooLarge returns ErrTooLarge when err comes from a body cut off by
+// http.MaxBytesReader, nil otherwise.
+func tooLarge(err error) error {
+	var maxBytes *http.MaxBytesError
+	if errors.As(err, &maxBytes) {
+		return fmt.Errorf("%w: body exceeds %d bytes", models.ErrTooLarge, maxBytes.Limit)
+	}
+	return nil
+}
+
+// maxTTL caps the ttl of a create request, in seconds. Far larger values
+// would overflow time.Duration into the past.
+const maxTTL = 10 * 365 * 24 * 60 * 60
+
+// requestExpiry resolves the optional expires_at or ttl of a create request
+// into an absolute expiry time.
+func requestExpiry(request models.Request, now time.Time) (*time.Time, error) {
+	switch {
+	case request.ExpiresAt != nil && request.TTL != 0:
+		return nil, fmt.Errorf("%w: expires_at and ttl are mutually exclusive", models.ErrValidation)
+	case request.TTL < 0:
+		return nil, fmt.Errorf("%w: ttl must be positive", models.ErrValidation)
+	case request.TTL > maxTTL:
+		return nil, fmt.Errorf("%w: ttl must not exceed %d seconds", models.ErrValidation, maxTTL)
+	case request.TTL > 0:
+		expiresAt := now.Add(time.Duration(request.TTL) * time.Second)
+		return &expiresAt, nil
+	case request.ExpiresAt != nil:
+		if !request.ExpiresAt.After(now) {
+			return nil, fmt.Errorf("%w: expires_at must be in the future", models.ErrValidation)
+		}
+		return request.ExpiresAt, nil
+	default:
+		return nil, nil
+	}
+}
+
+func newResponse(link *modellink.Link) models.Response {
+	return models.Response{
+		URL:       link.URL,
+		Alias:     link.Alias,
+		ExpiresAt: link.ExpiresAt,
+		Owner:     link.Owner,
+		Disabled:  link.Disabled,
+		CreatedAt: link.CreatedAt,
+	}
 }
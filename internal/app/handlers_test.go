@@ -5,11 +5,14 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	modellink "github.com/broadcast80/ozon-task/domain/model/link"
 	"github.com/broadcast80/ozon-task/internal/pkg/models"
@@ -19,17 +22,48 @@ type mockShortener struct {
 	cutLinkCalled     bool
 	getFullLinkCalled bool
 	cutLinkInput      string
+	cutLinkAlias      string
+	cutLinkExpiresAt  *time.Time
+	cutLinkOwner      string
 	getFullLinkInput  string
 	cutLinkResult     *modellink.Link
+	cutLinkCreated    bool
 	cutLinkErr        error
 	getFullLinkResult *modellink.Link
 	getFullLinkErr    error
+	ownedLinkInput    string
+	ownedLinkErr      error
+	deleteLinkCalled  bool
+	deleteLinkInput   string
+	deleteLinkErr     error
+	disableInput      string
+	disableValue      bool
+	disableErr        error
+	updateInput       string
+	updateResult      *modellink.Link
+	updateErr         error
+	revisions         []models.Revision
+	revisionsErr      error
+	listFilter        modellink.Filter
+	listResult        *modellink.Page
+	listErr           error
+	cutLinksInput     []modellink.Link
+	cutLinksResult    []modellink.BatchResult
+	cutLinksErr       error
 }
 
-func (m *mockShortener) CutLink(ctx context.Context, url string) (*modellink.Link, error) {
+func (m *mockShortener) CutLink(ctx context.Context, link modellink.Link) (*modellink.Link, bool, error) {
 	m.cutLinkCalled = true
-	m.cutLinkInput = url
-	return m.cutLinkResult, m.cutLinkErr
+	m.cutLinkInput = link.URL
+	m.cutLinkAlias = link.Alias
+	m.cutLinkExpiresAt = link.ExpiresAt
+	m.cutLinkOwner = link.Owner
+	return m.cutLinkResult, m.cutLinkCreated, m.cutLinkErr
+}
+
+func (m *mockShortener) CutLinks(ctx context.Context, links []modellink.Link) ([]modellink.BatchResult, error) {
+	m.cutLinksInput = links
+	return m.cutLinksResult, m.cutLinksErr
 }
 
 func (m *mockShortener) GetFullLink(ctx context.Context, alias string) (*modellink.Link, error) {
@@ -38,6 +72,66 @@ func (m *mockShortener) GetFullLink(ctx context.Context, alias string) (*modelli
 	return m.getFullLinkResult, m.getFullLinkErr
 }
 
+func (m *mockShortener) OwnedLink(ctx context.Context, alias string) (*modellink.Link, error) {
+	m.ownedLinkInput = alias
+	if m.ownedLinkErr != nil {
+		return nil, m.ownedLinkErr
+	}
+	return &modellink.Link{Alias: alias}, nil
+}
+
+func (m *mockShortener) DeleteLink(ctx context.Context, alias string) error {
+	m.deleteLinkCalled = true
+	m.deleteLinkInput = alias
+	return m.deleteLinkErr
+}
+
+func (m *mockShortener) UpdateLink(ctx context.Context, alias, url string) (*modellink.Link, error) {
+	m.updateInput = url
+	return m.updateResult, m.updateErr
+}
+
+func (m *mockShortener) LinkRevisions(ctx context.Context, alias string) ([]models.Revision, error) {
+	return m.revisions, m.revisionsErr
+}
+
+func (m *mockShortener) ListLinks(ctx context.Context, filter modellink.Filter) (*modellink.Page, error) {
+	m.listFilter = filter
+	return m.listResult, m.listErr
+}
+
+func (m *mockShortener) SetLinkDisabled(ctx context.Context, alias string, disabled bool) error {
+	m.disableInput = alias
+	m.disableValue = disabled
+	return m.disableErr
+}
+
+type mockAnalytics struct {
+	hits      []models.Hit
+	clientIPs []string
+	stats     *models.Stats
+	statsErr  error
+}
+
+func (m *mockAnalytics) Record(hit models.Hit, clientIP string) {
+	m.hits = append(m.hits, hit)
+	m.clientIPs = append(m.clientIPs, clientIP)
+}
+
+func (m *mockAnalytics) Stats(ctx context.Context, alias string) (*models.Stats, error) {
+	return m.stats, m.statsErr
+}
+
+func decodeError(t *testing.T, rr *httptest.ResponseRecorder) models.ErrorBody {
+	t.Helper()
+
+	var response models.ErrorResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode error envelope: %v", err)
+	}
+	return response.Error
+}
+
 func TestHandlers_Create_Success(t *testing.T) {
 
 	mockService := &mockShortener{
@@ -45,18 +139,19 @@ func TestHandlers_Create_Success(t *testing.T) {
 			Alias: "somelabuda",
 			URL:   "https://primerchik.com",
 		},
+		cutLinkCreated: true,
 	}
 
 	router := http.NewServeMux()
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
-	h := New(router, mockService, logger)
+	h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
 	h.MapHandlers()
 
 	requestBody := models.Request{URL: "https://primerchik.com"}
 	bodyBytes, _ := json.Marshal(requestBody)
 
-	req, _ := http.NewRequest("POST", "/", bytes.NewReader(bodyBytes))
+	req, _ := http.NewRequest("POST", "/api/v1/links", bytes.NewReader(bodyBytes))
 	req.Header.Set("Content-Type", "application/json")
 
 	rr := httptest.NewRecorder()
@@ -71,8 +166,8 @@ func TestHandlers_Create_Success(t *testing.T) {
 		t.Errorf("expected CutLink input %s, got %s", requestBody.URL, mockService.cutLinkInput)
 	}
 
-	if status := rr.Code; status != http.StatusOK {
-		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	if status := rr.Code; status != http.StatusCreated {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
 	}
 
 	response := modellink.Link{}
@@ -82,6 +177,164 @@ func TestHandlers_Create_Success(t *testing.T) {
 	}
 }
 
+func TestHandlers_Create_CustomAlias(t *testing.T) {
+
+	mockService := &mockShortener{
+		cutLinkResult: &modellink.Link{
+			Alias: "promo",
+			URL:   "https://primerchik.com",
+		},
+		cutLinkCreated: true,
+	}
+
+	router := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+	h.MapHandlers()
+
+	req, _ := http.NewRequest("POST", "/api/v1/links", bytes.NewReader([]byte(`{"url":"https://primerchik.com","alias":"promo"}`)))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if mockService.cutLinkAlias != "promo" {
+		t.Errorf("expected CutLink alias %s, got %s", "promo", mockService.cutLinkAlias)
+	}
+
+	if status := rr.Code; status != http.StatusCreated {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+}
+
+func TestHandlers_Create_TTL(t *testing.T) {
+
+	mockService := &mockShortener{
+		cutLinkResult:  &modellink.Link{Alias: "promo", URL: "https://primerchik.com"},
+		cutLinkCreated: true,
+	}
+
+	router := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+	h.MapHandlers()
+
+	before := time.Now()
+
+	req, _ := http.NewRequest("POST", "/api/v1/links", bytes.NewReader([]byte(`{"url":"https://primerchik.com","ttl":3600}`)))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rr.Code)
+	}
+
+	if mockService.cutLinkExpiresAt == nil {
+		t.Fatal("expected CutLink to receive an expiry")
+	}
+
+	if got := mockService.cutLinkExpiresAt.Sub(before); got < time.Hour || got > time.Hour+time.Minute {
+		t.Errorf("expected expiry about an hour from now, got %v", got)
+	}
+}
+
+func TestHandlers_Create_InvalidExpiry(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "ttl_and_expires_at", body: `{"url":"https://a.com","ttl":60,"expires_at":"2999-01-01T00:00:00Z"}`},
+		{name: "negative_ttl", body: `{"url":"https://a.com","ttl":-1}`},
+		{name: "ttl_overflow", body: `{"url":"https://a.com","ttl":9223372036854775807}`},
+		{name: "expires_at_in_past", body: `{"url":"https://a.com","expires_at":"2000-01-01T00:00:00Z"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockShortener{}
+
+			router := http.NewServeMux()
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+			h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+			h.MapHandlers()
+
+			req, _ := http.NewRequest("POST", "/api/v1/links", bytes.NewReader([]byte(tt.body)))
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", rr.Code)
+			}
+
+			if mockService.cutLinkCalled {
+				t.Error("CutLink should not be called")
+			}
+		})
+	}
+}
+
+func TestHandlers_Create_TooLarge(t *testing.T) {
+	mockService := &mockShortener{}
+
+	router := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+	h.MapHandlers()
+
+	body := `{"url":"https://a.com/` + strings.Repeat("a", maxBodySize) + `"}`
+	req, _ := http.NewRequest("POST", "/api/v1/links", strings.NewReader(body))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413, got %d", rr.Code)
+	}
+	if body := decodeError(t, rr); body.Code != codeTooLarge {
+		t.Errorf("expected code %s, got %s", codeTooLarge, body.Code)
+	}
+	if mockService.cutLinkCalled {
+		t.Error("CutLink should not be called")
+	}
+}
+
+func TestHandlers_Create_Reused(t *testing.T) {
+
+	mockService := &mockShortener{
+		cutLinkResult: &modellink.Link{
+			Alias: "somelabuda",
+			URL:   "https://primerchik.com",
+		},
+		cutLinkCreated: false,
+	}
+
+	router := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+	h.MapHandlers()
+
+	req, _ := http.NewRequest("POST", "/api/v1/links", bytes.NewReader([]byte(`{"url":"https://primerchik.com"}`)))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	response := models.Response{}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if response.Alias != "somelabuda" {
+		t.Errorf("expected result %s, got %s", "somelabuda", response.Alias)
+	}
+}
+
 func TestHandlers_Create_ServiceError(t *testing.T) {
 
 	mockService := &mockShortener{
@@ -91,13 +344,13 @@ func TestHandlers_Create_ServiceError(t *testing.T) {
 	router := http.NewServeMux()
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
-	h := New(router, mockService, logger)
+	h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
 	h.MapHandlers()
 
 	requestBody := models.Request{URL: "https://example.com"}
 	bodyBytes, _ := json.Marshal(requestBody)
 
-	req, _ := http.NewRequest("POST", "/", bytes.NewReader(bodyBytes))
+	req, _ := http.NewRequest("POST", "/api/v1/links", bytes.NewReader(bodyBytes))
 	req.Header.Set("Content-Type", "application/json")
 
 	rr := httptest.NewRecorder()
@@ -107,6 +360,100 @@ func TestHandlers_Create_ServiceError(t *testing.T) {
 	if status := rr.Code; status != http.StatusInternalServerError {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusInternalServerError)
 	}
+
+	if body := decodeError(t, rr); body.Code != codeInternal {
+		t.Errorf("expected code %s, got %s", codeInternal, body.Code)
+	}
+}
+
+func TestHandlers_Create_ErrorMapping(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "duplicate", err: models.ErrDuplicate, wantStatus: http.StatusConflict, wantCode: codeDuplicate},
+		{name: "alias_taken", err: models.ErrAliasTaken, wantStatus: http.StatusConflict, wantCode: codeAliasTaken},
+		{name: "validation", err: models.ErrValidation, wantStatus: http.StatusBadRequest, wantCode: codeValidation},
+		{name: "not_found", err: models.ErrNotFound, wantStatus: http.StatusNotFound, wantCode: codeNotFound},
+		{name: "expired", err: models.ErrExpired, wantStatus: http.StatusGone, wantCode: codeExpired},
+		{name: "storage_full", err: models.ErrStorageFull, wantStatus: http.StatusInsufficientStorage, wantCode: codeStorageFull},
+		{name: "unsafe_destination", err: &models.DestinationError{Reason: "private_address", Host: "10.0.0.1"}, wantStatus: http.StatusUnprocessableEntity, wantCode: codeUnsafeURL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockShortener{cutLinkErr: fmt.Errorf("wrapped: %w", tt.err)}
+
+			router := http.NewServeMux()
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+			h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+			h.MapHandlers()
+
+			req, _ := http.NewRequest("POST", "/api/v1/links", bytes.NewReader([]byte(`{"url":"https://example.com"}`)))
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("expected %d, got %d", tt.wantStatus, rr.Code)
+			}
+
+			if body := decodeError(t, rr); body.Code != tt.wantCode {
+				t.Errorf("expected code %s, got %s", tt.wantCode, body.Code)
+			}
+		})
+	}
+}
+
+func TestHandlers_Create_UnsafeDestinationReason(t *testing.T) {
+	mockService := &mockShortener{
+		cutLinkErr: fmt.Errorf("s.destinations.Check: %w", &models.DestinationError{Reason: "denied_domain", Host: "bad.example"}),
+	}
+
+	router := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+	h.MapHandlers()
+
+	req, _ := http.NewRequest("POST", "/api/v1/links", bytes.NewReader([]byte(`{"url":"https://bad.example"}`)))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", rr.Code)
+	}
+
+	if body := decodeError(t, rr); body.Reason != "denied_domain" {
+		t.Errorf("expected reason denied_domain, got %q", body.Reason)
+	}
+}
+
+func TestHandlers_Create_EmptyURL(t *testing.T) {
+	mockService := &mockShortener{}
+
+	router := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+	h.MapHandlers()
+
+	req, _ := http.NewRequest("POST", "/api/v1/links", bytes.NewReader([]byte(`{"url":""}`)))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+
+	if mockService.cutLinkCalled {
+		t.Error("CutLink should not be called")
+	}
 }
 
 func TestHandlers_Get_Success(t *testing.T) {
@@ -120,14 +467,10 @@ func TestHandlers_Get_Success(t *testing.T) {
 	router := http.NewServeMux()
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
-	h := New(router, mockService, logger)
+	h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
 	h.MapHandlers()
 
-	requestBody := models.Request{Alias: "short-123"}
-	bodyBytes, _ := json.Marshal(requestBody)
-
-	req, _ := http.NewRequest("GET", "/", bytes.NewReader(bodyBytes))
-	req.Header.Set("Content-Type", "application/json")
+	req, _ := http.NewRequest("GET", "/api/v1/links/diehard", nil)
 
 	rr := httptest.NewRecorder()
 
@@ -137,6 +480,10 @@ func TestHandlers_Get_Success(t *testing.T) {
 		t.Error("GetFullLink should be called")
 	}
 
+	if mockService.getFullLinkInput != "diehard" {
+		t.Errorf("expected GetFullLink input %s, got %s", "diehard", mockService.getFullLinkInput)
+	}
+
 	response := modellink.Link{}
 	json.Unmarshal(rr.Body.Bytes(), &response)
 	if response.URL != mockService.getFullLinkResult.URL {
@@ -144,21 +491,405 @@ func TestHandlers_Get_Success(t *testing.T) {
 	}
 }
 
-func TestHandlers_Get_InvalidJSON(t *testing.T) {
+func TestHandlers_Create_InvalidJSON(t *testing.T) {
 	mockService := &mockShortener{}
 	router := http.NewServeMux()
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
-	h := New(router, mockService, logger)
+	h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
 	h.MapHandlers()
 
-	req, _ := http.NewRequest("GET", "/", bytes.NewReader([]byte(`invalid json`)))
+	req, _ := http.NewRequest("POST", "/api/v1/links", bytes.NewReader([]byte(`invalid json`)))
 	req.Header.Set("Content-Type", "application/json")
 
 	rr := httptest.NewRecorder()
 	router.ServeHTTP(rr, req)
 
-	if rr.Code != http.StatusInternalServerError {
-		t.Errorf("expected 500, got %d", rr.Code)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+
+	if body := decodeError(t, rr); body.Code != codeValidation {
+		t.Errorf("expected code %s, got %s", codeValidation, body.Code)
+	}
+
+	if mockService.cutLinkCalled {
+		t.Error("CutLink should not be called")
+	}
+}
+
+func TestHandlers_Get_NotFound(t *testing.T) {
+	mockService := &mockShortener{getFullLinkErr: models.ErrNotFound}
+	router := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+	h.MapHandlers()
+
+	req, _ := http.NewRequest("GET", "/api/v1/links/unknown", nil)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+
+	if body := decodeError(t, rr); body.Code != codeNotFound {
+		t.Errorf("expected code %s, got %s", codeNotFound, body.Code)
+	}
+}
+
+func TestHandlers_Delete(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "success", wantStatus: http.StatusNoContent},
+		{name: "not_found", err: models.ErrNotFound, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockShortener{deleteLinkErr: tt.err}
+			router := http.NewServeMux()
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+			h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+			h.MapHandlers()
+
+			req, _ := http.NewRequest("DELETE", "/api/v1/links/abc", nil)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if mockService.deleteLinkInput != "abc" {
+				t.Errorf("expected DeleteLink input %s, got %s", "abc", mockService.deleteLinkInput)
+			}
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("expected %d, got %d", tt.wantStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestHandlers_Update(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		err        error
+		wantStatus int
+	}{
+		{name: "success", body: `{"url": "https://manderlay.com"}`, wantStatus: http.StatusOK},
+		{name: "empty_url", body: `{}`, wantStatus: http.StatusBadRequest},
+		{name: "invalid_json", body: `{`, wantStatus: http.StatusBadRequest},
+		{name: "too_large", body: `{"url": "https://manderlay.com/` + strings.Repeat("a", maxBodySize) + `"}`, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "not_found", body: `{"url": "https://manderlay.com"}`, err: models.ErrNotFound, wantStatus: http.StatusNotFound},
+		{name: "unsafe", body: `{"url": "http://10.0.0.1"}`, err: &models.DestinationError{Reason: "private_address"}, wantStatus: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockShortener{
+				updateResult: &modellink.Link{Alias: "abc", URL: "https://manderlay.com"},
+				updateErr:    tt.err,
+			}
+			router := http.NewServeMux()
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+			h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+			h.MapHandlers()
+
+			req, _ := http.NewRequest("PATCH", "/api/v1/links/abc", bytes.NewReader([]byte(tt.body)))
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, rr.Code)
+			}
+
+			if tt.wantStatus == http.StatusOK {
+				var response models.Response
+				json.Unmarshal(rr.Body.Bytes(), &response)
+				if mockService.updateInput != "https://manderlay.com" || response.URL != "https://manderlay.com" {
+					t.Errorf("expected the new URL, got input %s and response %+v", mockService.updateInput, response)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlers_Revisions(t *testing.T) {
+	replacedAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		revisions  []models.Revision
+		err        error
+		wantStatus int
+		wantCount  int
+	}{
+		{name: "history", revisions: []models.Revision{{URL: "https://dogville.com", ReplacedAt: replacedAt}}, wantStatus: http.StatusOK, wantCount: 1},
+		{name: "empty", wantStatus: http.StatusOK},
+		{name: "forbidden", err: models.ErrForbidden, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockShortener{revisions: tt.revisions, revisionsErr: tt.err}
+			router := http.NewServeMux()
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+			h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+			h.MapHandlers()
+
+			req, _ := http.NewRequest("GET", "/api/v1/links/abc/revisions", nil)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, rr.Code)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Alias     string             `json:"alias"`
+				Revisions *[]models.Revision `json:"revisions"`
+			}
+			json.Unmarshal(rr.Body.Bytes(), &response)
+			if response.Alias != "abc" || response.Revisions == nil || len(*response.Revisions) != tt.wantCount {
+				t.Errorf("unexpected response %s", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandlers_SetDisabled(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		err          error
+		wantDisabled bool
+		wantStatus   int
+	}{
+		{name: "disable", path: "/api/v1/links/abc/disable", wantDisabled: true, wantStatus: http.StatusNoContent},
+		{name: "enable", path: "/api/v1/links/abc/enable", wantDisabled: false, wantStatus: http.StatusNoContent},
+		{name: "not_found", path: "/api/v1/links/abc/disable", err: models.ErrNotFound, wantDisabled: true, wantStatus: http.StatusNotFound},
+		{name: "forbidden", path: "/api/v1/links/abc/enable", err: models.ErrForbidden, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockShortener{disableErr: tt.err}
+			router := http.NewServeMux()
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+			h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+			h.MapHandlers()
+
+			req, _ := http.NewRequest("POST", tt.path, nil)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if mockService.disableInput != "abc" || mockService.disableValue != tt.wantDisabled {
+				t.Errorf("expected SetLinkDisabled(abc, %v), got (%s, %v)", tt.wantDisabled, mockService.disableInput, mockService.disableValue)
+			}
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("expected %d, got %d", tt.wantStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestHandlers_Get_Disabled(t *testing.T) {
+	mockService := &mockShortener{
+		getFullLinkErr: fmt.Errorf("wrapped: %w", models.ErrDisabled),
+	}
+
+	router := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+	h.MapHandlers()
+
+	for _, path := range []string{"/api/v1/links/abc", "/abc"} {
+		req, _ := http.NewRequest("GET", path, nil)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusGone {
+			t.Errorf("GET %s: expected 410, got %d", path, rr.Code)
+		}
+	}
+}
+
+func TestHandlers_Redirect_Success(t *testing.T) {
+	mockService := &mockShortener{
+		getFullLinkResult: &modellink.Link{
+			Alias: "diehard",
+			URL:   "https://newyear.com",
+		},
+	}
+
+	router := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	analytics := &mockAnalytics{}
+
+	h := New(router, mockService, analytics, logger, http.StatusMovedPermanently)
+	h.MapHandlers()
+
+	req, _ := http.NewRequest("GET", "/diehard", nil)
+	req.RemoteAddr = "10.0.0.1:5555"
+	req.Header.Set("Referer", "https://news.com")
+	req.Header.Set("User-Agent", "curl/8.0")
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if mockService.getFullLinkInput != "diehard" {
+		t.Errorf("expected GetFullLink input %s, got %s", "diehard", mockService.getFullLinkInput)
+	}
+
+	if rr.Code != http.StatusMovedPermanently {
+		t.Errorf("expected 301, got %d", rr.Code)
+	}
+
+	if location := rr.Header().Get("Location"); location != "https://newyear.com" {
+		t.Errorf("expected Location %s, got %s", "https://newyear.com", location)
+	}
+
+	if len(analytics.hits) != 1 {
+		t.Fatalf("expected 1 recorded hit, got %d", len(analytics.hits))
+	}
+
+	hit := analytics.hits[0]
+	if hit.Alias != "diehard" || hit.Referer != "https://news.com" || hit.UserAgent != "curl/8.0" {
+		t.Errorf("unexpected hit %+v", hit)
+	}
+
+	if analytics.clientIPs[0] != "10.0.0.1" {
+		t.Errorf("expected client IP %s, got %s", "10.0.0.1", analytics.clientIPs[0])
+	}
+}
+
+func TestHandlers_Stats(t *testing.T) {
+	mockService := &mockShortener{}
+
+	router := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	analytics := &mockAnalytics{
+		stats: &models.Stats{
+			Alias: "diehard",
+			Total: 3,
+			Daily: []models.DailyCount{{Date: "2026-10-18", Count: 3}},
+		},
+	}
+
+	h := New(router, mockService, analytics, logger, http.StatusFound)
+	h.MapHandlers()
+
+	req, _ := http.NewRequest("GET", "/api/v1/links/diehard/stats", nil)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+
+	response := models.Stats{}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if response.Total != 3 || len(response.Daily) != 1 {
+		t.Errorf("unexpected stats %+v", response)
+	}
+
+	if mockService.ownedLinkInput != "diehard" {
+		t.Errorf("expected the ownership of diehard to be checked, got %q", mockService.ownedLinkInput)
+	}
+
+	if len(analytics.hits) != 0 {
+		t.Errorf("stats lookup must not be recorded as a hit")
+	}
+}
+
+func TestHandlers_Stats_NotFound(t *testing.T) {
+	mockService := &mockShortener{
+		ownedLinkErr: models.ErrNotFound,
+	}
+
+	router := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+	h.MapHandlers()
+
+	req, _ := http.NewRequest("GET", "/api/v1/links/unknown/stats", nil)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+}
+
+func TestHandlers_Redirect_NotFound(t *testing.T) {
+	mockService := &mockShortener{
+		getFullLinkErr: models.ErrNotFound,
+	}
+
+	router := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+	h.MapHandlers()
+
+	req, _ := http.NewRequest("GET", "/unknown", nil)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+}
+
+func TestHandlers_Redirect_Expired(t *testing.T) {
+	mockService := &mockShortener{
+		getFullLinkErr: fmt.Errorf("wrapped: %w", models.ErrExpired),
+	}
+
+	router := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+	h.MapHandlers()
+
+	req, _ := http.NewRequest("GET", "/expired", nil)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusGone {
+		t.Errorf("expected 410, got %d", rr.Code)
+	}
+}
+
+func TestHandlers_MapHandlers_InvalidRedirectStatus(t *testing.T) {
+	h := New(http.NewServeMux(), &mockShortener{}, &mockAnalytics{}, slog.New(slog.NewTextHandler(io.Discard, nil)), http.StatusOK)
+
+	if err := h.MapHandlers(); err == nil {
+		t.Error("expected error for unsupported redirect status")
 	}
 }
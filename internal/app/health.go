@@ -0,0 +1,66 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+const (
+	checkTimeout = 2 * time.Second
+
+	statusOK          = "ok"
+	statusUnavailable = "unavailable"
+)
+
+type check struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// AddReadinessCheck registers a dependency probed by /readyz. It must be
+// called before serving.
+func (h *handlers) AddReadinessCheck(name string, fn func(ctx context.Context) error) {
+	h.checks = append(h.checks, check{name: name, fn: fn})
+}
+
+// SetReady switches /readyz between reporting dependency status and 503.
+// The service is not ready until startup completes and again once shutdown
+// begins.
+func (h *handlers) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+func (h *handlers) Liveness(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, http.StatusOK, models.HealthResponse{Status: statusOK})
+}
+
+func (h *handlers) Readiness(w http.ResponseWriter, r *http.Request) {
+	if !h.ready.Load() {
+		h.writeJSON(w, http.StatusServiceUnavailable, models.HealthResponse{Status: statusUnavailable})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+	defer cancel()
+
+	status := http.StatusOK
+	response := models.HealthResponse{
+		Status: statusOK,
+		Checks: make(map[string]string, len(h.checks)),
+	}
+
+	for _, c := range h.checks {
+		if err := c.fn(ctx); err != nil {
+			status = http.StatusServiceUnavailable
+			response.Status = statusUnavailable
+			response.Checks[c.name] = err.Error()
+			continue
+		}
+		response.Checks[c.name] = statusOK
+	}
+
+	h.writeJSON(w, status, response)
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+func serveHealth(t *testing.T, h *handlers, path string) (int, models.HealthResponse) {
+	t.Helper()
+
+	req, _ := http.NewRequest("GET", path, nil)
+	rr := httptest.NewRecorder()
+	h.Handler().ServeHTTP(rr, req)
+
+	var response models.HealthResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode health response: %v", err)
+	}
+	return rr.Code, response
+}
+
+func newHealthHandlers(t *testing.T) *handlers {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := New(http.NewServeMux(), &mockShortener{}, &mockAnalytics{}, logger, http.StatusFound)
+	if err := h.MapHandlers(); err != nil {
+		t.Fatalf("failed to map handlers: %v", err)
+	}
+	return h
+}
+
+func TestHandlers_Liveness(t *testing.T) {
+	h := newHealthHandlers(t)
+
+	code, response := serveHealth(t, h, "/healthz")
+	if code != http.StatusOK || response.Status != statusOK {
+		t.Errorf("expected 200 ok, got %d %s", code, response.Status)
+	}
+}
+
+func TestHandlers_Readiness_NotReady(t *testing.T) {
+	h := newHealthHandlers(t)
+	h.AddReadinessCheck("storage", func(ctx context.Context) error { return nil })
+
+	code, response := serveHealth(t, h, "/readyz")
+	if code != http.StatusServiceUnavailable || response.Status != statusUnavailable {
+		t.Errorf("expected 503 before startup, got %d %s", code, response.Status)
+	}
+
+	h.SetReady(true)
+	h.SetReady(false)
+
+	if code, _ := serveHealth(t, h, "/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 while draining, got %d", code)
+	}
+}
+
+func TestHandlers_Readiness_Checks(t *testing.T) {
+	h := newHealthHandlers(t)
+	h.AddReadinessCheck("storage", func(ctx context.Context) error { return nil })
+	h.SetReady(true)
+
+	code, response := serveHealth(t, h, "/readyz")
+	if code != http.StatusOK {
+		t.Errorf("expected 200, got %d", code)
+	}
+	if response.Checks["storage"] != statusOK {
+		t.Errorf("expected storage ok, got %q", response.Checks["storage"])
+	}
+
+	h.AddReadinessCheck("cache", func(ctx context.Context) error { return errors.New("connection refused") })
+
+	code, response = serveHealth(t, h, "/readyz")
+	if code != http.StatusServiceUnavailable || response.Status != statusUnavailable {
+		t.Errorf("expected 503 unavailable, got %d %s", code, response.Status)
+	}
+	if response.Checks["storage"] != statusOK || response.Checks["cache"] != "connection refused" {
+		t.Errorf("unexpected checks %v", response.Checks)
+	}
+}
@@ -0,0 +1,120 @@
+package app
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 1000
+)
+
+// List serves a page of links. The query accepts owner, created_after,
+// created_before (RFC 3339), url (substring), domain, sort (created_at or
+// -created_at), limit and the cursor of the previous page.
+func (h *handlers) List(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFilter(r.URL.Query())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	page, err := h.service.ListLinks(r.Context(), filter)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	response := models.ListResponse{Links: make([]models.Response, 0, len(page.Links))}
+	for _, link := range page.Links {
+		response.Links = append(response.Links, newResponse(&link))
+	}
+	if page.Next != nil {
+		response.NextCursor = encodeCursor(*page.Next)
+	}
+
+	h.writeJSON(w, http.StatusOK, response)
+}
+
+func parseFilter(query url.Values) (modellink.Filter, error) {
+	filter := modellink.Filter{
+		Owner:       query.Get("owner"),
+		URLContains: query.Get("url"),
+		Domain:      strings.TrimSuffix(strings.ToLower(query.Get("domain")), "."),
+		Limit:       defaultPageSize,
+	}
+
+	var err error
+	if filter.CreatedAfter, err = parseTime(query, "created_after"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedBefore, err = parseTime(query, "created_before"); err != nil {
+		return filter, err
+	}
+
+	switch query.Get("sort") {
+	case "", "-created_at":
+	case "created_at":
+		filter.Ascending = true
+	default:
+		return filter, fmt.Errorf("%w: sort must be created_at or -created_at", models.ErrValidation)
+	}
+
+	if raw := query.Get("limit"); raw != "" {
+		filter.Limit, err = strconv.Atoi(raw)
+		if err != nil || filter.Limit < 1 || filter.Limit > maxPageSize {
+			return filter, fmt.Errorf("%w: limit must be between 1 and %d", models.ErrValidation, maxPageSize)
+		}
+	}
+
+	if raw := query.Get("cursor"); raw != "" {
+		cursor, err := decodeCursor(raw)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = &cursor
+	}
+
+	return filter, nil
+}
+
+func parseTime(query url.Values, key string) (time.Time, error) {
+	raw := query.Get(key)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s must be an RFC 3339 time", models.ErrValidation, key)
+	}
+
+	return t, nil
+}
+
+// Cursors are opaque to clients: base64url encoded JSON of the position.
+func encodeCursor(cursor modellink.Cursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(raw string) (modellink.Cursor, error) {
+	var cursor modellink.Cursor
+
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil || json.Unmarshal(data, &cursor) != nil {
+		return cursor, fmt.Errorf("%w: invalid cursor", models.ErrValidation)
+	}
+
+	return cursor, nil
+}
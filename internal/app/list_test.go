@@ -0,0 +1,122 @@
+package app
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+func TestHandlers_List(t *testing.T) {
+	createdAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	next := modellink.Cursor{CreatedAt: createdAt, ID: 42}
+
+	mockService := &mockShortener{
+		listResult: &modellink.Page{
+			Links: []modellink.Link{{URL: "https://dogville.com/", Alias: "abc", Owner: "grace", CreatedAt: createdAt}},
+			Next:  &next,
+		},
+	}
+
+	router := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+	h.MapHandlers()
+
+	req, _ := http.NewRequest("GET", "/api/v1/links?owner=grace&domain=DogVille.com.&url=sale&sort=created_at&limit=10"+
+		"&created_after=2030-01-01T00:00:00Z&cursor="+encodeCursor(modellink.Cursor{CreatedAt: createdAt, ID: 7}), nil)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	filter := mockService.listFilter
+	if filter.Owner != "grace" || filter.Domain != "dogville.com" || filter.URLContains != "sale" ||
+		!filter.Ascending || filter.Limit != 10 || !filter.CreatedAfter.Equal(createdAt) || !filter.CreatedBefore.IsZero() {
+		t.Errorf("unexpected filter %+v", filter)
+	}
+	if filter.After == nil || filter.After.ID != 7 || !filter.After.CreatedAt.Equal(createdAt) {
+		t.Errorf("expected the decoded cursor, got %+v", filter.After)
+	}
+
+	var response models.ListResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Links) != 1 || response.Links[0].Alias != "abc" || !response.Links[0].CreatedAt.Equal(createdAt) {
+		t.Errorf("unexpected links %+v", response.Links)
+	}
+
+	cursor, err := decodeCursor(response.NextCursor)
+	if err != nil || cursor.ID != 42 {
+		t.Errorf("next_cursor = %q decodes to %+v, %v", response.NextCursor, cursor, err)
+	}
+}
+
+func TestHandlers_List_Defaults(t *testing.T) {
+	mockService := &mockShortener{listResult: &modellink.Page{}}
+
+	router := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+	h.MapHandlers()
+
+	req, _ := http.NewRequest("GET", "/api/v1/links", nil)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if mockService.listFilter.Limit != defaultPageSize || mockService.listFilter.Ascending {
+		t.Errorf("unexpected default filter %+v", mockService.listFilter)
+	}
+	if body := rr.Body.String(); body != `{"links":[]}` {
+		t.Errorf("expected an empty list, got %s", body)
+	}
+}
+
+func TestHandlers_List_InvalidQuery(t *testing.T) {
+	for _, query := range []string{
+		"limit=0",
+		"limit=1001",
+		"limit=ten",
+		"sort=alias",
+		"created_before=yesterday",
+		"cursor=***",
+	} {
+		t.Run(query, func(t *testing.T) {
+			mockService := &mockShortener{listResult: &modellink.Page{}}
+
+			router := http.NewServeMux()
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+			h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+			h.MapHandlers()
+
+			req, _ := http.NewRequest("GET", "/api/v1/links?"+query, nil)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", rr.Code)
+			}
+			if body := decodeError(t, rr); body.Code != codeValidation {
+				t.Errorf("expected code %s, got %s", codeValidation, body.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/broadcast80/ozon-task/internal/pkg/metrics"
+)
+
+// unmatchedRoute labels requests that no pattern matched, so arbitrary
+// paths do not create new series.
+const unmatchedRoute = "unmatched"
+
+var (
+	httpRequests = metrics.Default.NewCounterVec(
+		"shortener_http_requests_total",
+		"HTTP requests served, by route pattern and status code.",
+		"route", "status",
+	)
+	httpDuration = metrics.Default.NewHistogramVec(
+		"shortener_http_request_duration_seconds",
+		"HTTP request latency, by route pattern and status code.",
+		metrics.DefBuckets,
+		"route", "status",
+	)
+)
+
+// instrument records request count and latency labelled by the ServeMux
+// pattern that handled the request.
+func instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(recorder, r)
+
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+
+		route := r.Pattern
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(recorder.status)
+
+		httpRequests.With(route, status).Inc()
+		httpDuration.With(route, status).ObserveSince(start)
+	})
+}
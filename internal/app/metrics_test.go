@@ -0,0 +1,61 @@
+package app
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+func TestHandlers_Metrics(t *testing.T) {
+	mockService := &mockShortener{
+		getFullLinkResult: &modellink.Link{Alias: "metered", URL: "https://example.com"},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := New(http.NewServeMux(), mockService, &mockAnalytics{}, logger, http.StatusFound)
+	if err := h.MapHandlers(); err != nil {
+		t.Fatalf("failed to map handlers: %v", err)
+	}
+	handler := h.Handler()
+
+	before := httpRequests.With("GET /{alias}", "302").Value()
+
+	req, _ := http.NewRequest("GET", "/metered", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	mockService.getFullLinkResult, mockService.getFullLinkErr = nil, models.ErrNotFound
+	req, _ = http.NewRequest("GET", "/api/v1/links/missing", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	req, _ = http.NewRequest("GET", "/a/b/c", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got := httpRequests.With("GET /{alias}", "302").Value() - before; got != 1 {
+		t.Errorf("expected 1 redirect counted, got %v", got)
+	}
+
+	req, _ = http.NewRequest("GET", "/metrics", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+
+	body := rr.Body.String()
+	for _, want := range []string{
+		`shortener_http_requests_total{route="GET /api/v1/links/{alias}",status="404"}`,
+		`shortener_http_requests_total{route="unmatched",status="404"}`,
+		`shortener_http_request_duration_seconds_bucket{route="GET /{alias}",status="302",le="+Inf"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in exposition:\n%s", want, body)
+		}
+	}
+}
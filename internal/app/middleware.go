@@ -0,0 +1,133 @@
+package app
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/broadcast80/ozon-task/internal/pkg/logging"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	// maxRequestIDLength bounds client supplied IDs, longer or non-printable
+	// values are replaced with a generated one.
+	maxRequestIDLength = 128
+)
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// withRequestID propagates a valid incoming X-Request-ID or assigns a new
+// one, echoes it in the response and stores a logger carrying it in the
+// request context.
+func (h *handlers) withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+
+		ctx := logging.WithLogger(r.Context(), h.logger.With("request_id", id))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// accessLog writes one line per request once the response is complete.
+func (h *handlers) accessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(recorder, r)
+
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+
+		logging.FromContext(r.Context(), h.logger).Info("request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", recorder.status,
+			"bytes", recorder.bytes,
+			"duration", time.Since(start),
+		)
+	})
+}
+
+// recoverPanic turns a panicking handler into a 500 response. Aborted
+// handlers are re-panicked so net/http drops the connection as intended.
+func (h *handlers) recoverPanic(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w}
+
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			logging.FromContext(r.Context(), h.logger).Error("panic while serving request",
+				"Error", fmt.Sprint(recovered),
+				"stack", string(debug.Stack()),
+			)
+
+			// the response is already on its way, there is nothing to fix
+			if recorder.status != 0 {
+				return
+			}
+			h.writeError(recorder, r, fmt.Errorf("panic: %v", recovered))
+		}()
+
+		next.ServeHTTP(recorder, r)
+	})
+}
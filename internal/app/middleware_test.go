@@ -0,0 +1,130 @@
+package app
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+func newMiddlewareHandlers(t *testing.T, logBuf *bytes.Buffer) *handlers {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(logBuf, nil))
+	h := New(http.NewServeMux(), &mockShortener{getFullLinkErr: models.ErrNotFound}, &mockAnalytics{}, logger, http.StatusFound)
+	if err := h.MapHandlers(); err != nil {
+		t.Fatalf("failed to map handlers: %v", err)
+	}
+	return h
+}
+
+func TestMiddleware_RequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "propagated", incoming: "req-42", keep: true},
+		{name: "generated", incoming: ""},
+		{name: "invalid", incoming: "bad id\n"},
+		{name: "too_long", incoming: strings.Repeat("a", maxRequestIDLength+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logBuf bytes.Buffer
+			h := newMiddlewareHandlers(t, &logBuf)
+
+			req, _ := http.NewRequest("GET", "/healthz", nil)
+			if tt.incoming != "" {
+				req.Header.Set(requestIDHeader, tt.incoming)
+			}
+
+			rr := httptest.NewRecorder()
+			h.Handler().ServeHTTP(rr, req)
+
+			id := rr.Header().Get(requestIDHeader)
+			if tt.keep && id != tt.incoming {
+				t.Errorf("expected request ID %q, got %q", tt.incoming, id)
+			}
+			if !tt.keep && (id == tt.incoming || len(id) != 32) {
+				t.Errorf("expected a generated request ID, got %q", id)
+			}
+			if !strings.Contains(logBuf.String(), "request_id="+id) {
+				t.Errorf("expected access log to carry the request ID, got %q", logBuf.String())
+			}
+		})
+	}
+}
+
+func TestMiddleware_AccessLog(t *testing.T) {
+	var logBuf bytes.Buffer
+	h := newMiddlewareHandlers(t, &logBuf)
+
+	req, _ := http.NewRequest("GET", "/missing", nil)
+	rr := httptest.NewRecorder()
+	h.Handler().ServeHTTP(rr, req)
+
+	lines := strings.Split(strings.TrimSpace(logBuf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 access log line, got %d: %q", len(lines), logBuf.String())
+	}
+
+	for _, want := range []string{"msg=request", "method=GET", "path=/missing", "status=404", "bytes=", "duration="} {
+		if !strings.Contains(lines[0], want) {
+			t.Errorf("expected %q in access log %q", want, lines[0])
+		}
+	}
+	if strings.Contains(lines[0], "bytes=0 ") {
+		t.Errorf("expected response size in access log %q", lines[0])
+	}
+}
+
+func TestMiddleware_RecoverPanic(t *testing.T) {
+	var logBuf bytes.Buffer
+	h := newMiddlewareHandlers(t, &logBuf)
+	h.router.HandleFunc("GET /api/v1/boom", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	req, _ := http.NewRequest("GET", "/api/v1/boom", nil)
+	req.Header.Set(requestIDHeader, "req-panic")
+
+	rr := httptest.NewRecorder()
+	h.Handler().ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rr.Code)
+	}
+	if body := decodeError(t, rr); body.Code != codeInternal {
+		t.Errorf("expected code %s, got %s", codeInternal, body.Code)
+	}
+
+	logs := logBuf.String()
+	for _, want := range []string{"panic while serving request", "Error=boom", "request_id=req-panic", "status=500"} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("expected %q in logs %q", want, logs)
+		}
+	}
+}
+
+func TestMiddleware_AbortHandler(t *testing.T) {
+	var logBuf bytes.Buffer
+	h := newMiddlewareHandlers(t, &logBuf)
+	h.router.HandleFunc("GET /api/v1/abort", func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	defer func() {
+		if recover() != http.ErrAbortHandler {
+			t.Error("expected http.ErrAbortHandler to be re-panicked")
+		}
+	}()
+
+	req, _ := http.NewRequest("GET", "/api/v1/abort", nil)
+	h.Handler().ServeHTTP(httptest.NewRecorder(), req)
+}
@@ -0,0 +1,168 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/broadcast80/ozon-task/internal/pkg/auth"
+	"github.com/broadcast80/ozon-task/internal/pkg/logging"
+	"github.com/broadcast80/ozon-task/internal/pkg/metrics"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+	"github.com/broadcast80/ozon-task/internal/pkg/ratelimit"
+)
+
+var rateLimited = metrics.Default.NewCounterVec(
+	"shortener_rate_limited_total",
+	"Requests rejected by the rate limiter, by route pattern.",
+	"route",
+)
+
+// RateLimiter decides whether the client identified by key may proceed
+// with a request weighing n tokens. ratelimit.TokenBucket keeps state in
+// process, a shared store can be plugged in behind the same method.
+type RateLimiter interface {
+	AllowN(ctx context.Context, key string, n int) (ratelimit.Decision, error)
+}
+
+// SetRateLimiters throttles link creation and alias resolution separately.
+// It must be called before MapHandlers, a nil limiter leaves its routes
+// unthrottled.
+func (h *handlers) SetRateLimiters(create, resolve RateLimiter) {
+	h.createLimiter = create
+	h.resolveLimiter = resolve
+}
+
+// SetBatchLimiter throttles POST /api/v1/links:batch by the number of
+// items, every item takes a token. It must be called before MapHandlers.
+func (h *handlers) SetBatchLimiter(limiter RateLimiter) {
+	h.batchLimiter = limiter
+}
+
+// SetAuthLimiter throttles API key lookups by client IP. It runs before
+// the key is resolved, so guessing keys is throttled along with using
+// them. It must be called before MapHandlers.
+func (h *handlers) SetAuthLimiter(limiter RateLimiter) {
+	h.authLimiter = limiter
+}
+
+// SetTrustedProxies lists the addresses or CIDR ranges whose
+// X-Forwarded-For header is believed when identifying the client.
+func (h *handlers) SetTrustedProxies(proxies []string) error {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+
+		if prefix, err := netip.ParsePrefix(proxy); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+
+	h.trustedProxies = prefixes
+	return nil
+}
+
+func (h *handlers) limit(limiter RateLimiter, next http.HandlerFunc) http.HandlerFunc {
+	if limiter == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.allow(w, r, limiter, h.rateLimitKey(r), 1) {
+			next(w, r)
+		}
+	}
+}
+
+// allow takes n tokens of key from limiter and reports whether the request
+// may proceed. A rejected request has been answered with 429.
+func (h *handlers) allow(w http.ResponseWriter, r *http.Request, limiter RateLimiter, key string, n int) bool {
+	decision, err := limiter.AllowN(r.Context(), key, n)
+	if err != nil {
+		// a broken shared backend must not take the service down
+		logging.FromContext(r.Context(), h.logger).Error("rate limiter failed", "Error", err.Error())
+		return true
+	}
+
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(decision.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds(decision.Reset)))
+
+	if !decision.Allowed {
+		rateLimited.With(r.Pattern).Inc()
+		w.Header().Set("Retry-After", strconv.Itoa(seconds(decision.RetryAfter)))
+		h.writeError(w, r, models.ErrRateLimited)
+		return false
+	}
+
+	return true
+}
+
+// seconds rounds d up, headers must not tell clients to retry too early.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// rateLimitKey buckets authenticated callers by owner, so one key is not
+// throttled by its neighbours behind the same NAT, and everyone else by IP.
+func (h *handlers) rateLimitKey(r *http.Request) string {
+	if principal, ok := auth.FromContext(r.Context()); ok {
+		return "owner:" + principal.Owner
+	}
+	return "ip:" + h.clientIP(r)
+}
+
+// clientIP returns the address of the client. When the peer is a trusted
+// proxy X-Forwarded-For is walked from the right, skipping trusted hops,
+// so a client cannot spoof its address by prepending entries.
+func (h *handlers) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	peer, err := netip.ParseAddr(host)
+	if err != nil || !h.trusted(peer) {
+		return host
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			break
+		}
+		peer = hop
+		if !h.trusted(hop) {
+			break
+		}
+	}
+
+	return peer.String()
+}
+
+func (h *handlers) trusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range h.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
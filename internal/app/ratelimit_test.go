@@ -0,0 +1,208 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/ratelimit"
+)
+
+type mockLimiter struct {
+	decision ratelimit.Decision
+	err      error
+	keys     []string
+	weights  []int
+}
+
+func (m *mockLimiter) AllowN(ctx context.Context, key string, n int) (ratelimit.Decision, error) {
+	m.keys = append(m.keys, key)
+	m.weights = append(m.weights, n)
+	return m.decision, m.err
+}
+
+func TestHandlers_RateLimit_Rejected(t *testing.T) {
+	mockService := &mockShortener{}
+	create := &mockLimiter{decision: ratelimit.Decision{
+		Limit: 10, Remaining: 0, Reset: 19500 * time.Millisecond, RetryAfter: 1500 * time.Millisecond,
+	}}
+
+	router := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+	h.SetRateLimiters(create, nil)
+	h.MapHandlers()
+
+	req, _ := http.NewRequest("POST", "/api/v1/links", bytes.NewBufferString(`{"url": "https://example.com"}`))
+	req.RemoteAddr = "10.0.0.1:5555"
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rr.Code)
+	}
+	if body := decodeError(t, rr); body.Code != codeRateLimited {
+		t.Errorf("expected code %s, got %s", codeRateLimited, body.Code)
+	}
+	if mockService.cutLinkCalled {
+		t.Error("expected CutLink not to be called")
+	}
+
+	for header, want := range map[string]string{
+		"Retry-After":         "2",
+		"RateLimit-Limit":     "10",
+		"RateLimit-Remaining": "0",
+		"RateLimit-Reset":     "20",
+	} {
+		if got := rr.Header().Get(header); got != want {
+			t.Errorf("expected %s %s, got %s", header, want, got)
+		}
+	}
+
+	if len(create.keys) != 1 || create.keys[0] != "ip:10.0.0.1" {
+		t.Errorf("unexpected limiter keys %v", create.keys)
+	}
+}
+
+func TestHandlers_RateLimit_SeparateLimits(t *testing.T) {
+	mockService := &mockShortener{
+		getFullLinkResult: &modellink.Link{Alias: "diehard", URL: "https://newyear.com"},
+	}
+	create := &mockLimiter{}
+	resolve := &mockLimiter{decision: ratelimit.Decision{Allowed: true, Limit: 100, Remaining: 99}}
+
+	router := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+	h.SetRateLimiters(create, resolve)
+	h.MapHandlers()
+
+	for _, path := range []string{"/diehard", "/api/v1/links/diehard"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Code == http.StatusTooManyRequests {
+			t.Errorf("%s: expected resolve limit to apply, got 429", path)
+		}
+		if rr.Header().Get("RateLimit-Remaining") != "99" {
+			t.Errorf("%s: expected RateLimit-Remaining 99, got %q", path, rr.Header().Get("RateLimit-Remaining"))
+		}
+	}
+
+	if len(create.keys) != 0 || len(resolve.keys) != 2 {
+		t.Errorf("expected only the resolve limiter to be used, got create=%d resolve=%d", len(create.keys), len(resolve.keys))
+	}
+}
+
+func TestHandlers_RateLimit_BatchByItems(t *testing.T) {
+	mockService := &mockShortener{}
+	create := &mockLimiter{decision: ratelimit.Decision{Allowed: true}}
+	batch := &mockLimiter{decision: ratelimit.Decision{Limit: 2, RetryAfter: time.Second}}
+
+	router := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+	h.SetRateLimiters(create, nil)
+	h.SetBatchLimiter(batch)
+	h.MapHandlers()
+
+	req, _ := http.NewRequest("POST", "/api/v1/links:batch", bytes.NewBufferString(`[{"url":"https://a.com"},{"url":"https://b.com"},{"url":"https://c.com"}]`))
+	req.RemoteAddr = "10.0.0.1:5555"
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rr.Code)
+	}
+	if mockService.cutLinksInput != nil {
+		t.Errorf("expected no links to be shortened, got %+v", mockService.cutLinksInput)
+	}
+	if len(batch.weights) != 1 || batch.weights[0] != 3 {
+		t.Errorf("expected one charge of 3 tokens, got %v", batch.weights)
+	}
+	if len(create.keys) != 0 {
+		t.Errorf("expected the create limiter not to be used, got %v", create.keys)
+	}
+}
+
+func TestHandlers_RateLimit_FailsOpen(t *testing.T) {
+	mockService := &mockShortener{
+		cutLinkResult:  &modellink.Link{Alias: "abc", URL: "https://example.com"},
+		cutLinkCreated: true,
+	}
+
+	router := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := New(router, mockService, &mockAnalytics{}, logger, http.StatusFound)
+	h.SetRateLimiters(&mockLimiter{err: errors.New("backend down")}, nil)
+	h.MapHandlers()
+
+	req, _ := http.NewRequest("POST", "/api/v1/links", bytes.NewBufferString(`{"url": "https://example.com"}`))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("expected 201 when the limiter fails, got %d", rr.Code)
+	}
+}
+
+func TestHandlers_ClientIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded []string
+		want      string
+	}{
+		{name: "direct", remote: "203.0.113.7:1234", want: "203.0.113.7"},
+		{name: "untrusted_peer_ignores_header", remote: "203.0.113.7:1234", forwarded: []string{"1.1.1.1"}, want: "203.0.113.7"},
+		{name: "trusted_proxy", remote: "10.0.0.2:1234", forwarded: []string{"198.51.100.9"}, want: "198.51.100.9"},
+		{name: "spoofed_prefix", remote: "10.0.0.2:1234", forwarded: []string{"1.1.1.1, 198.51.100.9"}, want: "198.51.100.9"},
+		{name: "proxy_chain", remote: "10.0.0.2:1234", forwarded: []string{"198.51.100.9, 192.168.1.1", "10.0.0.3"}, want: "198.51.100.9"},
+		{name: "garbage_stops_walk", remote: "10.0.0.2:1234", forwarded: []string{"not-an-ip"}, want: "10.0.0.2"},
+		{name: "no_header", remote: "10.0.0.2:1234", want: "10.0.0.2"},
+		{name: "ipv6_client", remote: "10.0.0.2:1234", forwarded: []string{"2001:db8::1"}, want: "2001:db8::1"},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := New(http.NewServeMux(), &mockShortener{}, &mockAnalytics{}, logger, http.StatusFound)
+	if err := h.SetTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"}); err != nil {
+		t.Fatalf("SetTrustedProxies() unexpected error: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remote
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+
+			if got := h.clientIP(req); got != tt.want {
+				t.Errorf("clientIP() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlers_SetTrustedProxies_Invalid(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := New(http.NewServeMux(), &mockShortener{}, &mockAnalytics{}, logger, http.StatusFound)
+
+	if err := h.SetTrustedProxies([]string{"proxy.local"}); err == nil {
+		t.Error("expected error for a hostname")
+	}
+}
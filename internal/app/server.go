@@ -0,0 +1,32 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/broadcast80/ozon-task/config"
+)
+
+func NewServer(cfg config.HTTPServer, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              net.JoinHostPort(cfg.Host, cfg.Port),
+		Handler:           handler,
+		ReadTimeout:       cfg.Timeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Timeout,
+		IdleTimeout:       cfg.Idle_timeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+}
+
+// ListenAndServe runs server until it is shut down. A graceful shutdown is
+// not reported as an error.
+func ListenAndServe(server *http.Server) error {
+	err := server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("listen and serve error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/broadcast80/ozon-task/config"
+)
+
+func TestNewServer(t *testing.T) {
+	cfg := config.HTTPServer{
+		Host:              "127.0.0.1",
+		Port:              "0",
+		Timeout:           4 * time.Second,
+		Idle_timeout:      time.Minute,
+		ReadHeaderTimeout: time.Second,
+		MaxHeaderBytes:    4096,
+	}
+
+	server := NewServer(cfg, http.NewServeMux())
+
+	if server.Addr != "127.0.0.1:0" {
+		t.Errorf("expected address %s, got %s", "127.0.0.1:0", server.Addr)
+	}
+	if server.ReadTimeout != cfg.Timeout || server.WriteTimeout != cfg.Timeout {
+		t.Errorf("expected read/write timeout %v, got %v/%v", cfg.Timeout, server.ReadTimeout, server.WriteTimeout)
+	}
+	if server.IdleTimeout != cfg.Idle_timeout {
+		t.Errorf("expected idle timeout %v, got %v", cfg.Idle_timeout, server.IdleTimeout)
+	}
+	if server.ReadHeaderTimeout != cfg.ReadHeaderTimeout {
+		t.Errorf("expected read header timeout %v, got %v", cfg.ReadHeaderTimeout, server.ReadHeaderTimeout)
+	}
+	if server.MaxHeaderBytes != cfg.MaxHeaderBytes {
+		t.Errorf("expected max header bytes %d, got %d", cfg.MaxHeaderBytes, server.MaxHeaderBytes)
+	}
+}
+
+func TestListenAndServe_GracefulShutdown(t *testing.T) {
+	server := NewServer(config.HTTPServer{Host: "127.0.0.1", Port: "0"}, http.NewServeMux())
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- ListenAndServe(server)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+
+	if err := server.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected shutdown error: %v", err)
+	}
+
+	select {
+	case err := <-errs:
+		if err != nil {
+			t.Errorf("expected nil after graceful shutdown, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ListenAndServe did not return after Shutdown")
+	}
+}
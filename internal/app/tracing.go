@@ -0,0 +1,58 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/broadcast80/ozon-task/internal/pkg/logging"
+	"github.com/broadcast80/ozon-task/internal/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/broadcast80/ozon-task/internal/app")
+
+// traceRequests starts a server span for every request, continuing the
+// trace from an incoming traceparent header. The span is renamed to the
+// matched route once the router has run.
+func traceRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		ctx, span := tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		if spanContext := span.SpanContext(); spanContext.IsValid() {
+			ctx = logging.With(ctx, "trace_id", spanContext.TraceID().String())
+		}
+
+		r = r.WithContext(ctx)
+		recorder := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(recorder, r)
+
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+
+		if r.Pattern != "" {
+			span.SetName(r.Pattern)
+			span.SetAttributes(semconv.HTTPRoute(r.Pattern))
+		}
+		if alias := r.PathValue("alias"); alias != "" {
+			span.SetAttributes(tracing.AliasKey.String(alias))
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(recorder.status))
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.status))
+		}
+	})
+}
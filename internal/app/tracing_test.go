@@ -0,0 +1,61 @@
+package app
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestHandlers_TraceParent(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	mockService := &mockShortener{
+		getFullLinkResult: &modellink.Link{Alias: "traced", URL: "https://example.com"},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := New(http.NewServeMux(), mockService, &mockAnalytics{}, logger, http.StatusFound)
+	if err := h.MapHandlers(); err != nil {
+		t.Fatalf("failed to map handlers: %v", err)
+	}
+
+	req, _ := http.NewRequest("GET", "/traced", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	h.Handler().ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+
+	span := spans[0]
+	if span.Name() != "GET /{alias}" {
+		t.Errorf("expected span name %q, got %q", "GET /{alias}", span.Name())
+	}
+	if got := span.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected trace ID from traceparent, got %s", got)
+	}
+	if got := span.Parent().SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("expected remote parent span, got %s", got)
+	}
+
+	attributes := attribute.NewSet(span.Attributes()...)
+	if v, _ := attributes.Value("link.alias"); v.AsString() != "traced" {
+		t.Errorf("expected alias attribute, got %q", v.AsString())
+	}
+	if v, _ := attributes.Value("http.response.status_code"); v.AsInt64() != http.StatusFound {
+		t.Errorf("expected status attribute 302, got %d", v.AsInt64())
+	}
+}
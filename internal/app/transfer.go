@@ -0,0 +1,118 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/broadcast80/ozon-task/internal/pkg/logging"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+	"github.com/broadcast80/ozon-task/internal/pkg/transfer"
+)
+
+// Transfer streams the whole link database out and in. Both calls are
+// limited to admins.
+type Transfer interface {
+	ExportLinks(ctx context.Context, w transfer.Writer) (int, error)
+	ImportLinks(ctx context.Context, r transfer.Reader, policy transfer.Policy) (transfer.Result, error)
+}
+
+// SetTransfer enables the admin export and import endpoints. It must be
+// called before MapHandlers, and the endpoints are only mapped together
+// with a key store.
+func (h *handlers) SetTransfer(t Transfer) {
+	h.transfer = t
+}
+
+// Export streams every link in the format given by the format query
+// parameter, jsonl by default. A failure after the first record cuts the
+// body short, the status is already sent by then.
+func (h *handlers) Export(w http.ResponseWriter, r *http.Request) {
+	format, err := transfer.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	if err := h.clearDeadlines(w, r, false); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="links.%s"`, format))
+
+	recorder := &statusRecorder{ResponseWriter: w}
+	n, err := h.transfer.ExportLinks(r.Context(), transfer.NewWriter(recorder, format))
+	if err != nil && recorder.status == 0 {
+		w.Header().Del("Content-Disposition")
+		h.writeError(w, r, err)
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("export interrupted", "exported", n, "Error", err.Error())
+	}
+}
+
+// Import stores the records of the request body. The format and
+// on_conflict query parameters select the encoding and what to do with
+// taken aliases: skip, overwrite or fail (the default). An import that
+// stops reports the error along with the counts of what it had stored.
+func (h *handlers) Import(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	format, err := transfer.ParseFormat(query.Get("format"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	policy, err := transfer.ParsePolicy(query.Get("on_conflict"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	if err := h.clearDeadlines(w, r, true); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	result, err := h.transfer.ImportLinks(r.Context(), transfer.NewReader(r.Body, format), policy)
+	response := models.ImportResponse{Imported: result.Imported, Skipped: result.Skipped}
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Warn("import stopped",
+			"imported", result.Imported, "skipped", result.Skipped, "Error", err.Error())
+
+		// records stored before the error stay, the client needs the counts
+		status, body := h.errorBody(r, err)
+		h.writeJSON(w, status, models.ImportErrorResponse{Error: body, ImportResponse: response})
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, response)
+}
+
+// clearDeadlines lifts the server timeouts, which are meant for regular
+// requests, off a transfer: the write deadline and, for a request body
+// read to the end, the read deadline. A writer without deadlines has
+// nothing to lift.
+func (h *handlers) clearDeadlines(w http.ResponseWriter, r *http.Request, read bool) error {
+	rc := http.NewResponseController(w)
+
+	err := rc.SetWriteDeadline(time.Time{})
+	if err == nil && read {
+		err = rc.SetReadDeadline(time.Time{})
+	}
+	if errors.Is(err, http.ErrNotSupported) {
+		logging.FromContext(r.Context(), h.logger).Warn("transfer runs under the server timeouts", "Error", err.Error())
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to clear deadlines: %w", err)
+	}
+
+	return nil
+}
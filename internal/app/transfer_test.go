@@ -0,0 +1,281 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/auth"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+	"github.com/broadcast80/ozon-task/internal/pkg/transfer"
+)
+
+type mockTransfer struct {
+	exportLinks []modellink.Link
+	exportErr   error
+
+	imported     []modellink.Link
+	importPolicy transfer.Policy
+	importResult transfer.Result
+	importErr    error
+	importDelay  time.Duration
+}
+
+func (m *mockTransfer) ExportLinks(ctx context.Context, w transfer.Writer) (int, error) {
+	if m.exportErr != nil {
+		return 0, m.exportErr
+	}
+	for _, link := range m.exportLinks {
+		if err := w.Write(link); err != nil {
+			return 0, err
+		}
+	}
+	return len(m.exportLinks), w.Flush()
+}
+
+func (m *mockTransfer) ImportLinks(ctx context.Context, r transfer.Reader, policy transfer.Policy) (transfer.Result, error) {
+	m.importPolicy = policy
+	time.Sleep(m.importDelay)
+	for {
+		link, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return m.importResult, err
+		}
+		m.imported = append(m.imported, link)
+	}
+	return m.importResult, m.importErr
+}
+
+var adminKeys = mockKeyStore{
+	auth.HashKey("admin-key"): {Owner: "ops", Admin: true},
+}
+
+func newTransferHandlers(t *testing.T, mock *mockTransfer) *http.ServeMux {
+	t.Helper()
+
+	router := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := New(router, &mockShortener{}, &mockAnalytics{}, logger, http.StatusFound)
+	h.SetTransfer(mock)
+	h.SetKeyStore(adminKeys)
+	if err := h.MapHandlers(); err != nil {
+		t.Fatalf("MapHandlers() error = %v", err)
+	}
+
+	return router
+}
+
+func TestHandlers_Export(t *testing.T) {
+	router := newTransferHandlers(t, &mockTransfer{
+		exportLinks: []modellink.Link{{URL: "https://a.com", Alias: "abc"}},
+	})
+
+	req, _ := http.NewRequest("GET", "/api/v1/admin/export?format=csv", nil)
+	req.Header.Set(apiKeyHeader, "admin-key")
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got := rr.Header().Get("Content-Type"); got != "text/csv" {
+		t.Errorf("expected text/csv, got %q", got)
+	}
+	if !strings.HasPrefix(rr.Body.String(), "alias,url,") || !strings.Contains(rr.Body.String(), "abc,https://a.com,") {
+		t.Errorf("unexpected body:\n%s", rr.Body.String())
+	}
+}
+
+func TestHandlers_Export_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		err        error
+		wantStatus int
+	}{
+		{name: "unknown_format", query: "?format=xml", wantStatus: http.StatusBadRequest},
+		{name: "forbidden", err: models.ErrForbidden, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTransferHandlers(t, &mockTransfer{exportErr: tt.err})
+
+			req, _ := http.NewRequest("GET", "/api/v1/admin/export"+tt.query, nil)
+			req.Header.Set(apiKeyHeader, "admin-key")
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+			if got := rr.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("expected a JSON error, got %q", got)
+			}
+		})
+	}
+}
+
+func TestHandlers_Import(t *testing.T) {
+	mock := &mockTransfer{importResult: transfer.Result{Imported: 1, Skipped: 1}}
+	router := newTransferHandlers(t, mock)
+
+	body := "{\"alias\":\"abc\",\"url\":\"https://a.com\"}\n{\"alias\":\"def\",\"url\":\"https://b.com\"}\n"
+	req, _ := http.NewRequest("POST", "/api/v1/admin/import?on_conflict=skip", strings.NewReader(body))
+	req.Header.Set(apiKeyHeader, "admin-key")
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	if mock.importPolicy != transfer.Skip || len(mock.imported) != 2 {
+		t.Errorf("unexpected import: policy %q, links %+v", mock.importPolicy, mock.imported)
+	}
+
+	var response models.ImportResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Imported != 1 || response.Skipped != 1 {
+		t.Errorf("unexpected response %+v", response)
+	}
+}
+
+func TestHandlers_Import_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		body       string
+		err        error
+		wantStatus int
+	}{
+		{name: "unknown_policy", query: "?on_conflict=merge", wantStatus: http.StatusBadRequest},
+		{name: "unknown_format", query: "?format=xml", wantStatus: http.StatusBadRequest},
+		{name: "malformed_record", body: `{"alias":`, wantStatus: http.StatusBadRequest},
+		{name: "conflict", err: models.ErrAliasTaken, wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTransferHandlers(t, &mockTransfer{importErr: tt.err})
+
+			req, _ := http.NewRequest("POST", "/api/v1/admin/import"+tt.query, strings.NewReader(tt.body))
+			req.Header.Set(apiKeyHeader, "admin-key")
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandlers_Import_StoppedCounts(t *testing.T) {
+	router := newTransferHandlers(t, &mockTransfer{
+		importResult: transfer.Result{Imported: 3, Skipped: 1},
+		importErr:    fmt.Errorf("record 5: %w: abc", models.ErrAliasTaken),
+	})
+
+	req, _ := http.NewRequest("POST", "/api/v1/admin/import", strings.NewReader(""))
+	req.Header.Set(apiKeyHeader, "admin-key")
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response models.ImportErrorResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Error.Code != codeAliasTaken || response.Imported != 3 || response.Skipped != 1 {
+		t.Errorf("unexpected response %+v", response)
+	}
+}
+
+func TestHandlers_Import_OutlivesWriteTimeout(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := New(http.NewServeMux(), &mockShortener{}, &mockAnalytics{}, logger, http.StatusFound)
+	h.SetTransfer(&mockTransfer{importDelay: 200 * time.Millisecond, importResult: transfer.Result{Imported: 1}})
+	h.SetKeyStore(adminKeys)
+	if err := h.MapHandlers(); err != nil {
+		t.Fatalf("MapHandlers() error = %v", err)
+	}
+
+	server := httptest.NewUnstartedServer(h.Handler())
+	server.Config.ReadTimeout = 50 * time.Millisecond
+	server.Config.WriteTimeout = 50 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	req, _ := http.NewRequest("POST", server.URL+"/api/v1/admin/import", strings.NewReader(`{"alias":"abc","url":"https://a.com"}`))
+	req.Header.Set(apiKeyHeader, "admin-key")
+
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("expected the result to be written, got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+}
+
+func TestHandlers_Transfer_NotMapped(t *testing.T) {
+	router := http.NewServeMux()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// a transfer without a key store would be open to anyone
+	h := New(router, &mockShortener{}, &mockAnalytics{}, logger, http.StatusFound)
+	h.SetTransfer(&mockTransfer{})
+	h.MapHandlers()
+
+	req, _ := http.NewRequest("POST", "/api/v1/admin/import", strings.NewReader(""))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusMethodNotAllowed && rr.Code != http.StatusNotFound {
+		t.Fatalf("expected the route to be missing, got %d", rr.Code)
+	}
+}
+
+func TestHandlers_Transfer_RequiresKey(t *testing.T) {
+	mock := &mockTransfer{}
+	router := newTransferHandlers(t, mock)
+
+	req, _ := http.NewRequest("POST", "/api/v1/admin/import", strings.NewReader(`{"alias":"abc","url":"https://a.com"}`))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(mock.imported) != 0 {
+		t.Errorf("expected nothing to be imported, got %+v", mock.imported)
+	}
+}
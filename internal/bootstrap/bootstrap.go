@@ -0,0 +1,157 @@
+// Package bootstrap builds the storage and alias generator selected by the
+// configuration, shared by the server and ozonctl.
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/broadcast80/ozon-task/config"
+	app "github.com/broadcast80/ozon-task/internal/app"
+	"github.com/broadcast80/ozon-task/internal/pkg/auth"
+	"github.com/broadcast80/ozon-task/internal/pkg/generator"
+	"github.com/broadcast80/ozon-task/internal/pkg/metrics"
+	"github.com/broadcast80/ozon-task/internal/pkg/utils"
+	"github.com/broadcast80/ozon-task/internal/repository/cache"
+	inmemory "github.com/broadcast80/ozon-task/internal/repository/in_memory"
+	"github.com/broadcast80/ozon-task/internal/repository/instrumented"
+	"github.com/broadcast80/ozon-task/internal/repository/postgresql"
+	"github.com/broadcast80/ozon-task/internal/usecase"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	StoragePostgres = "postgres"
+	StorageInMemory = "inmemory"
+)
+
+// StorageType returns the backend selected by STORAGE_TYPE, inmemory by
+// default.
+func StorageType() string {
+	if storageType := os.Getenv("STORAGE_TYPE"); storageType != "" {
+		return storageType
+	}
+	return StorageInMemory
+}
+
+// Storage bundles the repositories of the selected backend with its
+// readiness probe and cleanup.
+type Storage struct {
+	Repository usecase.RepositoryInterface
+	Stats      usecase.StatsRepository
+	Keys       app.KeyStore
+	Ping       func(ctx context.Context) error
+	Close      func()
+	// Durable is false for an in-memory storage without persistence.
+	Durable bool
+}
+
+func NewStorage(ctx context.Context, cfg config.Config, log *slog.Logger) (Storage, error) {
+	storageType := StorageType()
+
+	s := Storage{Close: func() {}}
+
+	switch storageType {
+
+	case StoragePostgres:
+		postgreSQLClient, err := NewPostgresClient(ctx, cfg)
+		if err != nil {
+			return Storage{}, err
+		}
+		utils.ExportPoolStats(metrics.Default, postgreSQLClient)
+		postgresRepository := postgresql.New(postgreSQLClient)
+		s.Repository, s.Stats = instrumented.New(postgresRepository, storageType), postgresRepository
+		s.Keys = postgresRepository
+		s.Durable = true
+		s.Ping, s.Close = postgresRepository.Ping, postgreSQLClient.Close
+
+	case StorageInMemory:
+		policy, err := inmemory.ParseEvictionPolicy(cfg.InMemoryConfig.Eviction)
+		if err != nil {
+			return Storage{}, fmt.Errorf("failed to init storage: %w", err)
+		}
+		keys, err := auth.NewStatic(cfg.AuthConfig.Keys)
+		if err != nil {
+			return Storage{}, fmt.Errorf("failed to load api keys: %w", err)
+		}
+		s.Keys = keys
+		if cfg.InMemoryConfig.PersistenceDir == "" {
+			inMemoryRepository := inmemory.New(cfg.InMemoryConfig.Size, policy)
+			exportEvictions(inMemoryRepository)
+			s.Repository, s.Stats = instrumented.New(inMemoryRepository, storageType), inMemoryRepository
+			s.Ping = inMemoryRepository.Ping
+			break
+		}
+
+		inMemoryRepository, err := inmemory.NewPersistent(
+			cfg.InMemoryConfig.Size, policy, cfg.InMemoryConfig.PersistenceDir, cfg.InMemoryConfig.Fsync, log,
+		)
+		if err != nil {
+			return Storage{}, fmt.Errorf("failed to init storage: %w", err)
+		}
+		s.Durable = true
+		inMemoryRepository.StartSnapshots(cfg.InMemoryConfig.SnapshotInterval, log)
+		s.Close = func() {
+			if err := inMemoryRepository.Close(); err != nil {
+				log.Error("failed to close storage", "Error", err.Error())
+			}
+		}
+		exportEvictions(inMemoryRepository)
+		s.Repository, s.Stats = instrumented.New(inMemoryRepository, storageType), inMemoryRepository
+		s.Ping = inMemoryRepository.Ping
+
+	default:
+		return Storage{}, fmt.Errorf("uknown STORAGE_TYPE %q", storageType)
+	}
+
+	// the in-memory store is a lookup table already, and a cache in front of
+	// it would hide recency from its LRU eviction and keep serving evicted
+	// aliases
+	if storageType == StoragePostgres && cfg.CacheConfig.Size > 0 {
+		cached := cache.New(s.Repository, cfg.CacheConfig.Size, cfg.CacheConfig.TTL, cfg.CacheConfig.NegativeTTL)
+		metrics.Default.NewCounterFunc("shortener_cache_hits_total", "Alias lookups served from the cache.",
+			func() float64 { hits, _ := cached.Stats(); return float64(hits) })
+		metrics.Default.NewCounterFunc("shortener_cache_misses_total", "Alias lookups that went to the storage.",
+			func() float64 { _, misses := cached.Stats(); return float64(misses) })
+		s.Repository = cached
+	}
+
+	return s, nil
+}
+
+// NewPostgresClient connects to the configured PostgreSQL database.
+func NewPostgresClient(ctx context.Context, cfg config.Config) (*pgxpool.Pool, error) {
+	client, err := utils.NewClient(ctx, 5, cfg.PostgresConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init storage: %w", err)
+	}
+	return client, nil
+}
+
+func exportEvictions(repository interface{ Evictions() uint64 }) {
+	metrics.Default.NewCounterFunc("shortener_inmemory_evictions_total", "Links evicted to keep the in-memory store within its size.",
+		func() float64 { return float64(repository.Evictions()) })
+}
+
+func NewGenerator(cfg config.Config) (usecase.AliasGenerator, error) {
+	switch cfg.GeneratorConfig.Type {
+
+	case "random":
+		return generator.NewRandom(cfg.GeneratorConfig.Length), nil
+
+	case "hash":
+		hashGenerator, err := generator.NewHash(cfg.GeneratorConfig.Length, cfg.GeneratorConfig.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to init alias generator: %w", err)
+		}
+		return hashGenerator, nil
+
+	case "counter":
+		return generator.NewCounter(cfg.GeneratorConfig.CounterStart), nil
+
+	default:
+		return nil, fmt.Errorf("unknown alias generator %q", cfg.GeneratorConfig.Type)
+	}
+}
@@ -0,0 +1,38 @@
+// Package auth hashes API keys and carries the authenticated principal in
+// a request context.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+type principalKey struct{}
+
+// HashKey returns the hex SHA-256 of key. Keys are random and long, so a
+// fast unsalted hash is enough to keep them unusable if the store leaks.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func WithPrincipal(ctx context.Context, principal models.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext returns the caller of the request, ok is false when the
+// request was not authenticated.
+func FromContext(ctx context.Context) (models.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(models.Principal)
+	return principal, ok
+}
+
+// CanModify reports whether the caller in ctx may change link. Without
+// authentication there is no ownership to enforce.
+func CanModify(ctx context.Context, owner string) bool {
+	principal, ok := FromContext(ctx)
+	return !ok || principal.Admin || principal.Owner == owner
+}
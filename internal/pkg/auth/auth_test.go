@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/broadcast80/ozon-task/config"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+func TestCanModify(t *testing.T) {
+	ctx := context.Background()
+
+	if !CanModify(ctx, "grace") {
+		t.Error("expected unauthenticated context to skip ownership checks")
+	}
+
+	if !CanModify(WithPrincipal(ctx, models.Principal{Owner: "grace"}), "grace") {
+		t.Error("expected owner to modify own link")
+	}
+
+	if CanModify(WithPrincipal(ctx, models.Principal{Owner: "tom"}), "grace") {
+		t.Error("expected another owner to be refused")
+	}
+
+	if !CanModify(WithPrincipal(ctx, models.Principal{Owner: "ops", Admin: true}), "grace") {
+		t.Error("expected admin to modify any link")
+	}
+}
+
+func TestStatic(t *testing.T) {
+	store, err := NewStatic([]config.APIKeyConfig{
+		{Owner: "grace", Hash: HashKey("grace-key")},
+		{Owner: "ops", Hash: HashKey("ops-key"), Admin: true},
+	})
+	if err != nil {
+		t.Fatalf("NewStatic() unexpected error: %v", err)
+	}
+
+	principal, err := store.LookupKey(context.Background(), HashKey("ops-key"))
+	if err != nil || principal.Owner != "ops" || !principal.Admin {
+		t.Errorf("LookupKey(ops) = %+v, %v", principal, err)
+	}
+
+	if _, err := store.LookupKey(context.Background(), HashKey("other")); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("LookupKey(other) error = %v, want %v", err, models.ErrNotFound)
+	}
+}
+
+func TestStatic_Invalid(t *testing.T) {
+	tests := map[string]config.APIKeyConfig{
+		"plain_key": {Owner: "grace", Hash: "grace-key"},
+		"short":     {Owner: "grace", Hash: strings.Repeat("a", 62)},
+		"no_owner":  {Hash: HashKey("key")},
+	}
+
+	for name, key := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewStatic([]config.APIKeyConfig{key}); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
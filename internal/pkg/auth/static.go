@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/broadcast80/ozon-task/config"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+// static is a key store loaded from configuration.
+type static struct {
+	keys map[string]models.Principal
+}
+
+func NewStatic(keys []config.APIKeyConfig) (*static, error) {
+	s := &static{keys: make(map[string]models.Principal, len(keys))}
+
+	for _, key := range keys {
+		if decoded, err := hex.DecodeString(key.Hash); err != nil || len(decoded) != 32 {
+			return nil, fmt.Errorf("api key of %q: hash must be a hex sha256", key.Owner)
+		}
+		if key.Owner == "" {
+			return nil, fmt.Errorf("api key %s...: owner is required", key.Hash[:8])
+		}
+
+		s.keys[key.Hash] = models.Principal{Owner: key.Owner, Admin: key.Admin}
+	}
+
+	return s, nil
+}
+
+func (s *static) LookupKey(ctx context.Context, hash string) (*models.Principal, error) {
+	principal, ok := s.keys[hash]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+	return &principal, nil
+}
@@ -0,0 +1,147 @@
+// Package destination decides whether a URL may be shortened based on the
+// addresses its host resolves to and on domain allow and deny lists.
+package destination
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+const (
+	ReasonPrivateAddress   = "private_address"
+	ReasonDeniedDomain     = "denied_domain"
+	ReasonDomainNotAllowed = "domain_not_allowed"
+	ReasonUnresolvableHost = "unresolvable_host"
+)
+
+// blockedPrefixes are special-purpose ranges not covered by the netip
+// predicates used in blocked.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),      // carrier-grade NAT
+	netip.MustParsePrefix("100.100.100.200/32"), // Alibaba Cloud metadata
+	netip.MustParsePrefix("168.63.129.16/32"),   // Azure host endpoint
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"), // NAT64 can reach IPv4 ranges above
+}
+
+// Resolver looks up the addresses of a host, *net.Resolver satisfies it.
+type Resolver interface {
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+type Checker struct {
+	resolver     Resolver
+	blockPrivate bool
+	timeout      time.Duration
+
+	lists atomic.Pointer[Lists]
+
+	stop chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+// New returns a checker that rejects hosts resolving to internal addresses
+// when blockPrivate is set. Lists are empty until SetLists or Watch.
+func New(resolver Resolver, blockPrivate bool, timeout time.Duration) *Checker {
+	c := &Checker{
+		resolver:     resolver,
+		blockPrivate: blockPrivate,
+		timeout:      timeout,
+		stop:         make(chan struct{}),
+	}
+	c.lists.Store(&Lists{})
+	return c
+}
+
+func (c *Checker) SetLists(lists *Lists) {
+	c.lists.Store(lists)
+}
+
+// Check returns a *models.DestinationError when rawURL must not be
+// shortened. rawURL is expected to be normalized already.
+func (c *Checker) Check(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: malformed url", models.ErrValidation)
+	}
+	host := u.Hostname()
+
+	if reason := c.lists.Load().check(host); reason != "" {
+		return &models.DestinationError{Reason: reason, Host: host}
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return c.checkAddrs(host, addr)
+	}
+
+	if !c.blockPrivate {
+		return nil
+	}
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	addrs, err := c.resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return &models.DestinationError{Reason: ReasonUnresolvableHost, Host: host}
+		}
+		return fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+	if len(addrs) == 0 {
+		return &models.DestinationError{Reason: ReasonUnresolvableHost, Host: host}
+	}
+
+	return c.checkAddrs(host, addrs...)
+}
+
+func (c *Checker) checkAddrs(host string, addrs ...netip.Addr) error {
+	if !c.blockPrivate {
+		return nil
+	}
+
+	// one internal address is enough, the client picks which one to use
+	for _, addr := range addrs {
+		if blocked(addr) {
+			return &models.DestinationError{Reason: ReasonPrivateAddress, Host: host}
+		}
+	}
+	return nil
+}
+
+// blocked reports whether addr is loopback, private, link-local (which
+// includes the 169.254.169.254 metadata endpoint) or otherwise not a
+// public unicast address.
+func blocked(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() || addr.IsUnspecified() {
+		return true
+	}
+
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,206 @@
+package destination
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+type fakeResolver map[string][]string
+
+func (f fakeResolver) LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error) {
+	ips, ok := f[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	addrs := make([]netip.Addr, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, netip.MustParseAddr(ip))
+	}
+	return addrs, nil
+}
+
+var resolver = fakeResolver{
+	"example.com":      {"93.184.216.34"},
+	"internal.corp":    {"10.1.2.3"},
+	"mixed.example":    {"93.184.216.35", "192.168.0.10"},
+	"metadata.example": {"169.254.169.254"},
+	"v6.example":       {"2606:2800:220:1::1"},
+	"v6local.example":  {"fd00::1"},
+	"mapped.example":   {"::ffff:127.0.0.1"},
+}
+
+func reason(t *testing.T, err error) string {
+	t.Helper()
+
+	if err == nil {
+		return ""
+	}
+	var destinationErr *models.DestinationError
+	if !errors.As(err, &destinationErr) {
+		t.Fatalf("expected *models.DestinationError, got %v", err)
+	}
+	if !errors.Is(err, models.ErrUnsafeDestination) {
+		t.Fatalf("expected error to wrap %v", models.ErrUnsafeDestination)
+	}
+	return destinationErr.Reason
+}
+
+func TestChecker_PrivateAddresses(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://example.com/", want: ""},
+		{url: "https://v6.example/", want: ""},
+		{url: "https://internal.corp/", want: ReasonPrivateAddress},
+		{url: "https://mixed.example/", want: ReasonPrivateAddress},
+		{url: "https://metadata.example/", want: ReasonPrivateAddress},
+		{url: "https://v6local.example/", want: ReasonPrivateAddress},
+		{url: "https://mapped.example/", want: ReasonPrivateAddress},
+		{url: "http://127.0.0.1/", want: ReasonPrivateAddress},
+		{url: "http://169.254.169.254/latest/meta-data", want: ReasonPrivateAddress},
+		{url: "http://100.100.100.200/", want: ReasonPrivateAddress},
+		{url: "http://[::1]/", want: ReasonPrivateAddress},
+		{url: "http://[fe80::1]/", want: ReasonPrivateAddress},
+		{url: "http://0.0.0.0/", want: ReasonPrivateAddress},
+		{url: "http://8.8.8.8/", want: ""},
+		{url: "https://nonexistent.example/", want: ReasonUnresolvableHost},
+	}
+
+	c := New(resolver, true, time.Second)
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := reason(t, c.Check(context.Background(), tt.url)); got != tt.want {
+				t.Errorf("Check(%s) reason = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChecker_PrivateAllowed(t *testing.T) {
+	c := New(resolver, false, time.Second)
+
+	for _, url := range []string{"https://internal.corp/", "http://127.0.0.1/", "https://nonexistent.example/"} {
+		if err := c.Check(context.Background(), url); err != nil {
+			t.Errorf("Check(%s) unexpected error: %v", url, err)
+		}
+	}
+}
+
+type failingResolver struct{}
+
+func (failingResolver) LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error) {
+	return nil, &net.DNSError{Err: "i/o timeout", Name: host, IsTimeout: true}
+}
+
+func TestChecker_ResolverFailure(t *testing.T) {
+	c := New(failingResolver{}, true, time.Second)
+
+	err := c.Check(context.Background(), "https://example.com/")
+	if err == nil || errors.Is(err, models.ErrUnsafeDestination) {
+		t.Fatalf("expected a plain resolver error, got %v", err)
+	}
+}
+
+func TestChecker_Lists(t *testing.T) {
+	lists, err := ParseLists(strings.NewReader(`
+# phishing
+deny bad.example
+DENY Пример.рф
+
+allow example.com
+allow bad.example
+`))
+	if err != nil {
+		t.Fatalf("ParseLists() unexpected error: %v", err)
+	}
+
+	c := New(resolver, false, time.Second)
+	c.SetLists(lists)
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://example.com/", want: ""},
+		{url: "https://www.example.com/", want: ""},
+		{url: "https://bad.example/", want: ReasonDeniedDomain},
+		{url: "https://login.bad.example/", want: ReasonDeniedDomain},
+		{url: "https://xn--e1afmkfd.xn--p1ai/", want: ReasonDeniedDomain},
+		{url: "https://other.org/", want: ReasonDomainNotAllowed},
+		{url: "https://notexample.com/", want: ReasonDomainNotAllowed},
+		{url: "http://8.8.8.8/", want: ReasonDomainNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := reason(t, c.Check(context.Background(), tt.url)); got != tt.want {
+				t.Errorf("Check(%s) reason = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLists_Invalid(t *testing.T) {
+	for _, input := range []string{"block example.com", "deny", "deny a b", "deny -bad-.com"} {
+		if _, err := ParseLists(strings.NewReader(input)); err == nil {
+			t.Errorf("ParseLists(%q) expected error", input)
+		}
+	}
+}
+
+func TestChecker_WatchReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "destinations.txt")
+	if err := os.WriteFile(path, []byte("deny bad.example\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := New(resolver, false, time.Second)
+	if err := c.Watch(path, 10*time.Millisecond, slog.New(slog.NewTextHandler(io.Discard, nil))); err != nil {
+		t.Fatalf("Watch() unexpected error: %v", err)
+	}
+	defer c.Stop()
+
+	if got := reason(t, c.Check(context.Background(), "https://bad.example/")); got != ReasonDeniedDomain {
+		t.Fatalf("expected initial lists to apply, got %q", got)
+	}
+
+	if err := os.WriteFile(path, []byte("deny worse.example\n# bad.example is fine now\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for reason(t, c.Check(context.Background(), "https://worse.example/")) != ReasonDeniedDomain {
+		if time.Now().After(deadline) {
+			t.Fatal("lists were not reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if got := reason(t, c.Check(context.Background(), "https://bad.example/")); got != "" {
+		t.Errorf("expected bad.example to be allowed after reload, got %q", got)
+	}
+
+	// a broken file keeps the previous lists
+	if err := os.WriteFile(path, []byte("nonsense\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if got := reason(t, c.Check(context.Background(), "https://worse.example/")); got != ReasonDeniedDomain {
+		t.Errorf("expected previous lists to stay after a failed reload, got %q", got)
+	}
+}
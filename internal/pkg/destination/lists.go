@@ -0,0 +1,166 @@
+package destination
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/idna"
+)
+
+// Lists holds domain rules. A rule matches the domain itself and all of
+// its subdomains. When the allow list is not empty only matching domains
+// may be shortened, the deny list is applied on top of it.
+type Lists struct {
+	allow map[string]struct{}
+	deny  map[string]struct{}
+}
+
+// ParseLists reads one rule per line, "deny example.com" or
+// "allow example.org". Blank lines and lines starting with # are skipped.
+func ParseLists(r io.Reader) (*Lists, error) {
+	lists := &Lists{
+		allow: make(map[string]struct{}),
+		deny:  make(map[string]struct{}),
+	}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: want \"allow|deny <domain>\", got %q", line, text)
+		}
+
+		domain, err := idna.Lookup.ToASCII(strings.TrimSuffix(fields[1], "."))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid domain %q: %w", line, fields[1], err)
+		}
+		domain = strings.ToLower(domain)
+
+		switch strings.ToLower(fields[0]) {
+		case "allow":
+			lists.allow[domain] = struct{}{}
+		case "deny":
+			lists.deny[domain] = struct{}{}
+		default:
+			return nil, fmt.Errorf("line %d: unknown rule %q", line, fields[0])
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return lists, nil
+}
+
+func LoadLists(path string) (*Lists, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	lists, err := ParseLists(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return lists, nil
+}
+
+// check returns the reason host is rejected, or an empty string.
+func (l *Lists) check(host string) string {
+	if matches(l.deny, host) {
+		return ReasonDeniedDomain
+	}
+	if len(l.allow) > 0 && !matches(l.allow, host) {
+		return ReasonDomainNotAllowed
+	}
+	return ""
+}
+
+// matches looks host and each of its parent domains up in rules.
+func matches(rules map[string]struct{}, host string) bool {
+	if len(rules) == 0 {
+		return false
+	}
+
+	host = strings.TrimSuffix(host, ".")
+	for {
+		if _, ok := rules[host]; ok {
+			return true
+		}
+		_, parent, ok := strings.Cut(host, ".")
+		if !ok {
+			return false
+		}
+		host = parent
+	}
+}
+
+// Watch loads the lists from path and reloads them whenever the file's
+// modification time or size changes. A file that fails to parse keeps the
+// previous lists in place.
+func (c *Checker) Watch(path string, interval time.Duration, logger *slog.Logger) error {
+	lists, err := LoadLists(path)
+	if err != nil {
+		return err
+	}
+	c.SetLists(lists)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		modTime, size := info.ModTime(), info.Size()
+		for {
+			select {
+			case <-c.stop:
+				return
+			case <-ticker.C:
+				info, err := os.Stat(path)
+				if err != nil {
+					logger.Error("failed to stat destination lists", "Error", err.Error())
+					continue
+				}
+				if info.ModTime().Equal(modTime) && info.Size() == size {
+					continue
+				}
+				modTime, size = info.ModTime(), info.Size()
+
+				lists, err := LoadLists(path)
+				if err != nil {
+					logger.Error("failed to reload destination lists", "Error", err.Error())
+					continue
+				}
+				c.SetLists(lists)
+				logger.Info("reloaded destination lists", "allow", len(lists.allow), "deny", len(lists.deny))
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop ends the reload loop started by Watch.
+func (c *Checker) Stop() {
+	c.once.Do(func() { close(c.stop) })
+	c.wg.Wait()
+}
@@ -0,0 +1,31 @@
+package generator
+
+import (
+	"math/big"
+	"sync/atomic"
+	"time"
+)
+
+type counter struct {
+	next atomic.Uint64
+}
+
+// NewCounter returns a generator that base63-encodes a monotonically
+// increasing ID. With start == 0 the counter is seeded from the current
+// time in milliseconds so that restarts don't reissue old IDs.
+func NewCounter(start uint64) *counter {
+	if start == 0 {
+		start = uint64(time.Now().UnixMilli())
+	}
+
+	g := &counter{}
+	g.next.Store(start)
+
+	return g
+}
+
+func (g *counter) Generate(url string, attempt int) string {
+	id := g.next.Add(1) - 1
+
+	return encode(new(big.Int).SetUint64(id))
+}
@@ -0,0 +1,30 @@
+package generator
+
+import "math/big"
+
+// Charset is the base63 alphabet used by every generator.
+const Charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_"
+
+var base = big.NewInt(int64(len(Charset)))
+
+// encode writes n in base63 using Charset, most significant digit first.
+func encode(n *big.Int) string {
+	if n.Sign() == 0 {
+		return Charset[:1]
+	}
+
+	n = new(big.Int).Set(n)
+	mod := new(big.Int)
+
+	var digits []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, base, mod)
+		digits = append(digits, Charset[mod.Int64()])
+	}
+
+	for i, j := 0, len(digits)-1; i < j; i, j = i+1, j-1 {
+		digits[i], digits[j] = digits[j], digits[i]
+	}
+
+	return string(digits)
+}
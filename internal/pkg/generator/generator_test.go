@@ -0,0 +1,87 @@
+package generator
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{n: 0, want: "a"},
+		{n: 1, want: "b"},
+		{n: 62, want: "_"},
+		{n: 63, want: "ba"},
+	}
+
+	for _, tt := range tests {
+		if got := encode(big.NewInt(tt.n)); got != tt.want {
+			t.Errorf("encode(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestRandom_Generate(t *testing.T) {
+	g := NewRandom(10)
+
+	alias := g.Generate("https://example.com", 0)
+	if len(alias) != 10 {
+		t.Fatalf("expected length 10, got %d", len(alias))
+	}
+
+	for _, c := range alias {
+		if !strings.ContainsRune(Charset, c) {
+			t.Fatalf("alias %q contains %q outside of charset", alias, c)
+		}
+	}
+}
+
+func TestHash_Generate(t *testing.T) {
+	for _, algorithm := range []string{"sha256", "md5"} {
+		t.Run(algorithm, func(t *testing.T) {
+			g, err := NewHash(6, algorithm)
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+
+			first := g.Generate("https://example.com", 0)
+			if len(first) != 6 {
+				t.Fatalf("expected length 6, got %d", len(first))
+			}
+
+			if again := g.Generate("https://example.com", 0); again != first {
+				t.Fatalf("expected deterministic alias %q, got %q", first, again)
+			}
+
+			if other := g.Generate("https://example.org", 0); other == first {
+				t.Fatalf("expected different aliases for different URLs, got %q", other)
+			}
+
+			retry := g.Generate("https://example.com", 2)
+			if len(retry) != 8 || !strings.HasPrefix(retry, first) {
+				t.Fatalf("expected %q extended by 2 characters, got %q", first, retry)
+			}
+		})
+	}
+}
+
+func TestNewHash_UnknownAlgorithm(t *testing.T) {
+	if _, err := NewHash(6, "crc32"); err == nil {
+		t.Fatal("expected error for unknown algorithm")
+	}
+}
+
+func TestCounter_Generate(t *testing.T) {
+	g := NewCounter(63)
+
+	if got := g.Generate("https://example.com", 0); got != "ba" {
+		t.Fatalf("expected %q, got %q", "ba", got)
+	}
+
+	if got := g.Generate("https://example.com", 0); got != "bb" {
+		t.Fatalf("expected %q, got %q", "bb", got)
+	}
+}
@@ -0,0 +1,43 @@
+package generator
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"math/big"
+)
+
+type hashed struct {
+	length int
+	newFn  func() hash.Hash
+}
+
+// NewHash returns a generator that truncates the base63 digest of the URL,
+// so the same URL always maps to the same alias. Every retry after a
+// collision takes one more character of the digest.
+func NewHash(length int, algorithm string) (*hashed, error) {
+	var newFn func() hash.Hash
+
+	switch algorithm {
+	case "sha256":
+		newFn = sha256.New
+	case "md5":
+		newFn = md5.New
+	default:
+		return nil, fmt.Errorf("unknown hash algorithm: %s", algorithm)
+	}
+
+	return &hashed{length: length, newFn: newFn}, nil
+}
+
+func (g *hashed) Generate(url string, attempt int) string {
+	h := g.newFn()
+	h.Write([]byte(url))
+
+	digest := encode(new(big.Int).SetBytes(h.Sum(nil)))
+
+	size := min(g.length+attempt, len(digest))
+
+	return digest[:size]
+}
@@ -0,0 +1,16 @@
+package generator
+
+import "github.com/broadcast80/ozon-task/internal/pkg/utils"
+
+type random struct {
+	length int
+}
+
+// NewRandom returns a generator producing random aliases of a fixed length.
+func NewRandom(length int) *random {
+	return &random{length: length}
+}
+
+func (g *random) Generate(url string, attempt int) string {
+	return utils.Encode(g.length, Charset)
+}
@@ -0,0 +1,33 @@
+// Package logging carries a request-scoped slog.Logger in a context so logs
+// written deep in the call chain keep the request attributes.
+package logging
+
+import (
+	"context"
+	"log/slog"
+)
+
+type loggerKey struct{}
+
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger stored in ctx, or fallback when there is
+// none, e.g. in background jobs.
+func FromContext(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return fallback
+}
+
+// With adds attributes to the logger stored in ctx. It is a no-op when ctx
+// carries no logger.
+func With(ctx context.Context, args ...any) context.Context {
+	logger, ok := ctx.Value(loggerKey{}).(*slog.Logger)
+	if !ok {
+		return ctx
+	}
+	return WithLogger(ctx, logger.With(args...))
+}
@@ -0,0 +1,32 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	fallback := slog.New(slog.NewTextHandler(&buf, nil))
+
+	ctx := context.Background()
+	if FromContext(ctx, fallback) != fallback {
+		t.Fatal("expected fallback logger for a bare context")
+	}
+
+	if With(ctx, "trace_id", "abc") != ctx {
+		t.Fatal("With must not add a logger to a bare context")
+	}
+
+	ctx = WithLogger(ctx, fallback.With("request_id", "42"))
+	ctx = With(ctx, "trace_id", "abc")
+
+	FromContext(ctx, nil).Info("hello")
+
+	if line := buf.String(); !strings.Contains(line, "request_id=42") || !strings.Contains(line, "trace_id=abc") {
+		t.Errorf("expected request attributes in %q", line)
+	}
+}
@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+type Counter struct {
+	bits atomic.Uint64
+}
+
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add increases the counter, negative values are ignored.
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		return
+	}
+	for {
+		old := c.bits.Load()
+		updated := math.Float64bits(math.Float64frombits(old) + v)
+		if c.bits.CompareAndSwap(old, updated) {
+			return
+		}
+	}
+}
+
+func (c *Counter) Value() float64 {
+	return math.Float64frombits(c.bits.Load())
+}
+
+// CounterVec is a family of counters partitioned by label values.
+type CounterVec struct {
+	desc
+	mu     sync.RWMutex
+	series map[string]*Counter
+}
+
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{
+		desc:   desc{metricName: name, help: help, labels: labels},
+		series: make(map[string]*Counter),
+	}
+	r.register(c)
+	return c
+}
+
+// NewCounter registers a counter without labels.
+func (r *Registry) NewCounter(name, help string) *Counter {
+	return r.NewCounterVec(name, help).With()
+}
+
+func (c *CounterVec) With(values ...string) *Counter {
+	c.checkLabels(values)
+	key := seriesKey(values)
+
+	c.mu.RLock()
+	counter, ok := c.series[key]
+	c.mu.RUnlock()
+	if ok {
+		return counter
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if counter, ok = c.series[key]; !ok {
+		counter = &Counter{}
+		c.series[key] = counter
+	}
+	return counter
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.writeHeader(w, "counter")
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, key := range sortedSeries(c.series) {
+		values := splitKey(key, len(c.labels))
+		fmt.Fprintf(w, "%s%s %s\n", c.metricName, formatLabels(c.labels, values), formatFloat(c.series[key].Value()))
+	}
+}
+
+func splitKey(key string, n int) []string {
+	if n == 0 {
+		return nil
+	}
+	return strings.Split(key, "\xff")
+}
+
+// funcMetric samples fn on every scrape, used to expose values owned by
+// other components such as pool statistics.
+type funcMetric struct {
+	desc
+	kind string
+	fn   func() float64
+}
+
+// NewGaugeFunc registers a gauge whose value is read from fn.
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{desc: desc{metricName: name, help: help}, kind: "gauge", fn: fn})
+}
+
+// NewCounterFunc registers a counter whose value is read from fn, fn must
+// be monotonic.
+func (r *Registry) NewCounterFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{desc: desc{metricName: name, help: help}, kind: "counter", fn: fn})
+}
+
+func (f *funcMetric) write(w *bufio.Writer) {
+	f.writeHeader(w, f.kind)
+	fmt.Fprintf(w, "%s %s\n", f.metricName, formatFloat(f.fn()))
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Histogram struct {
+	mu     sync.Mutex
+	upper  []float64
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.upper, v)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += v
+}
+
+// ObserveSince records the seconds elapsed since start.
+func (h *Histogram) ObserveSince(start time.Time) {
+	h.Observe(time.Since(start).Seconds())
+}
+
+// HistogramVec is a family of histograms partitioned by label values.
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.RWMutex
+	series  map[string]*Histogram
+}
+
+// NewHistogramVec registers a histogram family, buckets are upper bounds
+// and must be sorted, nil means DefBuckets.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	if !sort.Float64sAreSorted(buckets) {
+		panic(fmt.Sprintf("metrics: %s buckets are not sorted", name))
+	}
+
+	h := &HistogramVec{
+		desc:    desc{metricName: name, help: help, labels: labels},
+		buckets: buckets,
+		series:  make(map[string]*Histogram),
+	}
+	r.register(h)
+	return h
+}
+
+func (h *HistogramVec) With(values ...string) *Histogram {
+	h.checkLabels(values)
+	key := seriesKey(values)
+
+	h.mu.RLock()
+	histogram, ok := h.series[key]
+	h.mu.RUnlock()
+	if ok {
+		return histogram
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if histogram, ok = h.series[key]; !ok {
+		histogram = &Histogram{upper: h.buckets, counts: make([]uint64, len(h.buckets))}
+		h.series[key] = histogram
+	}
+	return histogram
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.writeHeader(w, "histogram")
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, key := range sortedSeries(h.series) {
+		values := splitKey(key, len(h.labels))
+		histogram := h.series[key]
+
+		histogram.mu.Lock()
+		var cumulative uint64
+		for i, upper := range histogram.upper {
+			cumulative += histogram.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, formatLabels(h.labels, values, "le", formatFloat(upper)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, formatLabels(h.labels, values, "le", "+Inf"), histogram.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metricName, formatLabels(h.labels, values), formatFloat(histogram.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metricName, formatLabels(h.labels, values), histogram.count)
+		histogram.mu.Unlock()
+	}
+}
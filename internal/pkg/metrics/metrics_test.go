@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func render(t *testing.T, r *Registry) string {
+	t.Helper()
+
+	var b strings.Builder
+	if err := r.WriteText(&b); err != nil {
+		t.Fatalf("WriteText() unexpected error: %v", err)
+	}
+	return b.String()
+}
+
+func TestCounterVec_WriteText(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounterVec("http_requests_total", "Requests served.", "route", "status")
+
+	requests.With("GET /{alias}", "302").Inc()
+	requests.With("GET /{alias}", "302").Add(2)
+	requests.With("POST /api/v1/links", "201").Inc()
+	requests.With("POST /api/v1/links", "201").Add(-5)
+
+	want := `# HELP http_requests_total Requests served.
+# TYPE http_requests_total counter
+http_requests_total{route="GET /{alias}",status="302"} 3
+http_requests_total{route="POST /api/v1/links",status="201"} 1
+`
+	if got := render(t, r); got != want {
+		t.Errorf("WriteText() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHistogram_WriteText(t *testing.T) {
+	r := NewRegistry()
+	latency := r.NewHistogramVec("latency_seconds", "Latency.", []float64{0.1, 1}, "op")
+
+	h := latency.With("get")
+	h.Observe(0.05)
+	h.Observe(0.1)
+	h.Observe(0.5)
+	h.Observe(3)
+
+	want := `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{op="get",le="0.1"} 2
+latency_seconds_bucket{op="get",le="1"} 3
+latency_seconds_bucket{op="get",le="+Inf"} 4
+latency_seconds_sum{op="get"} 3.65
+latency_seconds_count{op="get"} 4
+`
+	if got := render(t, r); got != want {
+		t.Errorf("WriteText() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRegistry_SortsAndEscapes(t *testing.T) {
+	r := NewRegistry()
+	r.NewGaugeFunc("b_gauge", "Line one\nline two.", func() float64 { return 2.5 })
+	r.NewCounterVec("a_total", "Counter.", "path").With(`say "hi"\`).Inc()
+
+	want := `# HELP a_total Counter.
+# TYPE a_total counter
+a_total{path="say \"hi\"\\"} 1
+# HELP b_gauge Line one\nline two.
+# TYPE b_gauge gauge
+b_gauge 2.5
+`
+	if got := render(t, r); got != want {
+		t.Errorf("WriteText() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRegistry_DuplicatePanics(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("links_total", "Links.")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic on duplicate metric")
+		}
+	}()
+	r.NewCounter("links_total", "Links.")
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("links_total", "Links.").Inc()
+
+	rr := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if ct := rr.Header().Get("Content-Type"); ct != contentType {
+		t.Errorf("expected Content-Type %s, got %s", contentType, ct)
+	}
+	if !strings.Contains(rr.Body.String(), "links_total 1\n") {
+		t.Errorf("unexpected body %s", rr.Body.String())
+	}
+}
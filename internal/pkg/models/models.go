@@ -1,11 +1,145 @@
 package models
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 type Request struct {
 	URL   string `json:"url"`
 	Alias string `json:"alias"`
+	// ExpiresAt and TTL (in seconds) are mutually exclusive.
+	ExpiresAt *time.Time `json:"expires_at"`
+	TTL       int64      `json:"ttl"`
+}
+
+// UpdateRequest repoints an existing alias to a new URL.
+type UpdateRequest struct {
+	URL string `json:"url"`
+}
+
+type Response struct {
+	URL       string     `json:"url"`
+	Alias     string     `json:"alias"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Owner     string     `json:"owner,omitempty"`
+	Disabled  bool       `json:"disabled,omitempty"`
+	CreatedAt time.Time  `json:"created_at,omitzero"`
+}
+
+type BatchResponse struct {
+	// Results follow the order of the request items.
+	Results []BatchItem `json:"results"`
+}
+
+// BatchItem is the outcome of one item of a batch: the link with status
+// 201 or 200 like for a single create, or the error otherwise.
+type BatchItem struct {
+	Status int        `json:"status"`
+	Link   *Response  `json:"link,omitempty"`
+	Error  *ErrorBody `json:"error,omitempty"`
+}
+
+type ListResponse struct {
+	Links []Response `json:"links"`
+	// NextCursor is passed as cursor to get the next page, it is empty on
+	// the last one.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type ImportResponse struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+}
+
+// ImportErrorResponse reports a stopped import together with the counts of
+// the records stored before it stopped.
+type ImportErrorResponse struct {
+	Error ErrorBody `json:"error"`
+	ImportResponse
+}
+
+// Principal is the authenticated caller behind an API key.
+type Principal struct {
+	Owner string
+	// Admin keys act on links of every owner.
+	Admin bool
+}
+
+// Hit is a single successful resolution of an alias.
+type Hit struct {
+	Alias     string
+	At        time.Time
+	Referer   string
+	UserAgent string
+	// IPHash is a salted SHA-256 of the client IP, the raw IP is never stored.
+	IPHash string
+}
+
+type Stats struct {
+	Alias        string       `json:"alias"`
+	Total        int64        `json:"total"`
+	LastAccessAt *time.Time   `json:"last_access_at,omitempty"`
+	Daily        []DailyCount `json:"daily"`
+}
+
+type DailyCount struct {
+	Date  string `json:"date"`
+	Count int64  `json:"count"`
+}
+
+// Revision is a destination a link pointed to before it was updated.
+type Revision struct {
+	URL        string    `json:"url"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+type RevisionsResponse struct {
+	Alias     string     `json:"alias"`
+	Revisions []Revision `json:"revisions"`
+}
+
+type HealthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Reason refines Code for rejected destinations.
+	Reason string `json:"reason,omitempty"`
 }
 
 var ErrDuplicate = errors.New("duplicate url")
 var ErrNotFound = errors.New("no such url")
+var ErrValidation = errors.New("validation failed")
+var ErrAliasTaken = fmt.Errorf("%w: alias is already taken", ErrDuplicate)
+var ErrExpired = errors.New("link expired")
+var ErrDisabled = errors.New("link disabled")
+var ErrStorageFull = errors.New("storage is full")
+var ErrRateLimited = errors.New("rate limit exceeded")
+var ErrUnauthorized = errors.New("missing or invalid api key")
+var ErrForbidden = errors.New("link belongs to another owner")
+var ErrUnsafeDestination = errors.New("destination is not allowed")
+var ErrTooLarge = errors.New("request is too large")
+
+// DestinationError rejects a URL because of where it points, Reason is a
+// machine-readable code.
+type DestinationError struct {
+	Reason string
+	Host   string
+}
+
+func (e *DestinationError) Error() string {
+	return fmt.Sprintf("%s: %s (%s)", ErrUnsafeDestination, e.Host, e.Reason)
+}
+
+func (e *DestinationError) Unwrap() error {
+	return ErrUnsafeDestination
+}
@@ -0,0 +1,126 @@
+// Package ratelimit implements per-key token buckets kept in process memory.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often buckets that have refilled completely are
+// dropped, so one-off clients do not accumulate.
+const sweepInterval = time.Minute
+
+// Decision is the outcome of a single Allow or AllowN call.
+type Decision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next token, zero when allowed.
+	RetryAfter time.Duration
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// TokenBucket allows burst requests at once and refills at rate tokens per
+// second. A shared backend can replace it by satisfying the same Allow
+// method.
+type TokenBucket struct {
+	rate  float64
+	burst int
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	return &TokenBucket{
+		rate:      rate,
+		burst:     max(burst, 1),
+		now:       time.Now,
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// PerMinute returns a bucket refilling limit tokens per minute.
+func PerMinute(limit, burst int) *TokenBucket {
+	return NewTokenBucket(float64(limit)/60, burst)
+}
+
+func (t *TokenBucket) Allow(ctx context.Context, key string) (Decision, error) {
+	return t.AllowN(ctx, key, 1)
+}
+
+// AllowN takes n tokens of key at once or none, so a request weighing n
+// is throttled like n requests. More than the burst is never allowed.
+func (t *TokenBucket) AllowN(ctx context.Context, key string, n int) (Decision, error) {
+	now := t.now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if now.Sub(t.lastSweep) >= sweepInterval {
+		t.sweep(now)
+	}
+
+	b, ok := t.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(t.burst), last: now}
+		t.buckets[key] = b
+	}
+
+	b.tokens = t.refill(b, now)
+	b.last = now
+
+	decision := Decision{Limit: t.burst}
+	if b.tokens >= float64(n) {
+		b.tokens -= float64(n)
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = t.duration(float64(n) - b.tokens)
+	}
+
+	decision.Remaining = int(b.tokens)
+	decision.Reset = t.duration(float64(t.burst) - b.tokens)
+
+	return decision, nil
+}
+
+func (t *TokenBucket) refill(b *bucket, now time.Time) float64 {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed <= 0 {
+		return b.tokens
+	}
+	return math.Min(float64(t.burst), b.tokens+elapsed*t.rate)
+}
+
+// duration returns how long it takes to refill tokens.
+func (t *TokenBucket) duration(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	if t.rate <= 0 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(tokens / t.rate * float64(time.Second))
+}
+
+// sweep drops buckets that are full again, they are indistinguishable from
+// new ones. The caller must hold t.mu.
+func (t *TokenBucket) sweep(now time.Time) {
+	for key, b := range t.buckets {
+		if t.refill(b, now) >= float64(t.burst) {
+			delete(t.buckets, key)
+		}
+	}
+	t.lastSweep = now
+}
@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestBucket(rate float64, burst int) (*TokenBucket, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	t := NewTokenBucket(rate, burst)
+	t.now = clock.Now
+	t.lastSweep = clock.now
+	return t, clock
+}
+
+func TestTokenBucket_Burst(t *testing.T) {
+	ctx := context.Background()
+	limiter, _ := newTestBucket(1, 3)
+
+	for i := range 3 {
+		decision, _ := limiter.Allow(ctx, "10.0.0.1")
+		if !decision.Allowed {
+			t.Fatalf("request %d: expected allowed", i)
+		}
+		if decision.Limit != 3 || decision.Remaining != 2-i {
+			t.Fatalf("request %d: unexpected decision %+v", i, decision)
+		}
+	}
+
+	decision, _ := limiter.Allow(ctx, "10.0.0.1")
+	if decision.Allowed {
+		t.Fatal("expected request over the burst to be rejected")
+	}
+	if decision.RetryAfter != time.Second {
+		t.Errorf("expected RetryAfter 1s, got %s", decision.RetryAfter)
+	}
+	if decision.Reset != 3*time.Second {
+		t.Errorf("expected Reset 3s, got %s", decision.Reset)
+	}
+
+	if decision, _ := limiter.Allow(ctx, "10.0.0.2"); !decision.Allowed {
+		t.Error("expected another key to have its own bucket")
+	}
+}
+
+func TestTokenBucket_AllowN(t *testing.T) {
+	ctx := context.Background()
+	limiter, clock := newTestBucket(1, 5)
+
+	if decision, _ := limiter.AllowN(ctx, "key", 4); !decision.Allowed || decision.Remaining != 1 {
+		t.Fatalf("expected 4 of 5 tokens to be taken, got %+v", decision)
+	}
+
+	// a partial take would let a heavy request through in pieces
+	decision, _ := limiter.AllowN(ctx, "key", 3)
+	if decision.Allowed || decision.Remaining != 1 {
+		t.Fatalf("expected rejection without taking tokens, got %+v", decision)
+	}
+	if decision.RetryAfter != 2*time.Second {
+		t.Errorf("expected RetryAfter 2s, got %s", decision.RetryAfter)
+	}
+
+	clock.now = clock.now.Add(2 * time.Second)
+	if decision, _ := limiter.AllowN(ctx, "key", 3); !decision.Allowed {
+		t.Errorf("expected allowed after refill, got %+v", decision)
+	}
+}
+
+func TestTokenBucket_Refill(t *testing.T) {
+	ctx := context.Background()
+	limiter, clock := newTestBucket(2, 2)
+
+	limiter.Allow(ctx, "key")
+	limiter.Allow(ctx, "key")
+
+	if decision, _ := limiter.Allow(ctx, "key"); decision.Allowed {
+		t.Fatal("expected empty bucket to reject")
+	}
+
+	clock.now = clock.now.Add(500 * time.Millisecond)
+
+	if decision, _ := limiter.Allow(ctx, "key"); !decision.Allowed {
+		t.Fatal("expected one token after half a second")
+	}
+	if decision, _ := limiter.Allow(ctx, "key"); decision.Allowed {
+		t.Fatal("expected bucket to be empty again")
+	}
+}
+
+func TestTokenBucket_SweepsFullBuckets(t *testing.T) {
+	ctx := context.Background()
+	limiter, clock := newTestBucket(1, 5)
+
+	limiter.Allow(ctx, "idle")
+
+	clock.now = clock.now.Add(sweepInterval)
+	limiter.Allow(ctx, "active")
+
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	if _, ok := limiter.buckets["idle"]; ok {
+		t.Error("expected refilled bucket to be swept")
+	}
+	if _, ok := limiter.buckets["active"]; !ok {
+		t.Error("expected active bucket to be kept")
+	}
+}
@@ -0,0 +1,25 @@
+package tracing
+
+import (
+	"errors"
+
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	AliasKey   = attribute.Key("link.alias")
+	BackendKey = attribute.Key("storage.backend")
+)
+
+// End records err on span and ends it. Missing aliases and collisions are
+// part of normal operation and do not mark the span as failed.
+func End(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, models.ErrNotFound) && !errors.Is(err, models.ErrDuplicate) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
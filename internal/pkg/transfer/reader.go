@@ -0,0 +1,176 @@
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+// maxLineLength bounds a JSON Lines record, stored URLs are far shorter.
+const maxLineLength = 1 << 20
+
+// Reader decodes links one by one and returns io.EOF after the last one.
+// Malformed records are reported as ErrValidation.
+type Reader interface {
+	Read() (modellink.Link, error)
+}
+
+func NewReader(r io.Reader, format Format) Reader {
+	if format == CSV {
+		cr := csv.NewReader(r)
+		cr.ReuseRecord = true
+		return &csvReader{r: cr}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineLength)
+	return &jsonReader{scanner: scanner}
+}
+
+func (rec record) link() (modellink.Link, error) {
+	if rec.Alias == "" {
+		return modellink.Link{}, fmt.Errorf("%w: alias is required", models.ErrValidation)
+	}
+	if rec.URL == "" {
+		return modellink.Link{}, fmt.Errorf("%w: url is required", models.ErrValidation)
+	}
+
+	return modellink.Link{
+		URL:       rec.URL,
+		Alias:     rec.Alias,
+		ExpiresAt: rec.ExpiresAt,
+		Owner:     rec.Owner,
+		Disabled:  rec.Disabled,
+		CreatedAt: rec.CreatedAt,
+	}, nil
+}
+
+type jsonReader struct {
+	scanner *bufio.Scanner
+}
+
+func (r *jsonReader) Read() (modellink.Link, error) {
+	for r.scanner.Scan() {
+		line := bytes.TrimSpace(r.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.DisallowUnknownFields()
+
+		var rec record
+		if err := decoder.Decode(&rec); err != nil {
+			return modellink.Link{}, fmt.Errorf("%w: malformed record: %s", models.ErrValidation, err.Error())
+		}
+		if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+			return modellink.Link{}, fmt.Errorf("%w: malformed record: trailing data", models.ErrValidation)
+		}
+
+		return rec.link()
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return modellink.Link{}, fmt.Errorf("%w: failed to read records: %s", models.ErrValidation, err.Error())
+	}
+
+	return modellink.Link{}, io.EOF
+}
+
+type csvReader struct {
+	r *csv.Reader
+	// index maps a column name to its position in the header
+	index map[string]int
+}
+
+func (r *csvReader) Read() (modellink.Link, error) {
+	if r.index == nil {
+		if err := r.readHeader(); err != nil {
+			return modellink.Link{}, err
+		}
+	}
+
+	fields, err := r.r.Read()
+	if errors.Is(err, io.EOF) {
+		return modellink.Link{}, io.EOF
+	} else if err != nil {
+		return modellink.Link{}, fmt.Errorf("%w: malformed record: %s", models.ErrValidation, err.Error())
+	}
+
+	field := func(name string) string {
+		if i, ok := r.index[name]; ok {
+			return fields[i]
+		}
+		return ""
+	}
+
+	rec := record{
+		Alias: field("alias"),
+		URL:   field("url"),
+		Owner: field("owner"),
+	}
+
+	if value := field("created_at"); value != "" {
+		if rec.CreatedAt, err = time.Parse(time.RFC3339Nano, value); err != nil {
+			return modellink.Link{}, fmt.Errorf("%w: created_at must be RFC 3339", models.ErrValidation)
+		}
+	}
+
+	if value := field("expires_at"); value != "" {
+		expiresAt, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return modellink.Link{}, fmt.Errorf("%w: expires_at must be RFC 3339", models.ErrValidation)
+		}
+		rec.ExpiresAt = &expiresAt
+	}
+
+	if value := field("disabled"); value != "" {
+		if rec.Disabled, err = strconv.ParseBool(value); err != nil {
+			return modellink.Link{}, fmt.Errorf("%w: disabled must be true or false", models.ErrValidation)
+		}
+	}
+
+	return rec.link()
+}
+
+// readHeader requires the alias and url columns, the others are optional
+// and may come in any order.
+func (r *csvReader) readHeader() error {
+	header, err := r.r.Read()
+	if errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: header is missing", models.ErrValidation)
+	} else if err != nil {
+		return fmt.Errorf("%w: malformed header: %s", models.ErrValidation, err.Error())
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		if !slices.Contains(columns, name) {
+			return fmt.Errorf("%w: unknown column %q", models.ErrValidation, name)
+		}
+		if _, ok := index[name]; ok {
+			return fmt.Errorf("%w: duplicate column %q", models.ErrValidation, name)
+		}
+		index[name] = i
+	}
+
+	for _, name := range []string{"alias", "url"} {
+		if _, ok := index[name]; !ok {
+			return fmt.Errorf("%w: column %q is required", models.ErrValidation, name)
+		}
+	}
+
+	r.index = index
+
+	return nil
+}
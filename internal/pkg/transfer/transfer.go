@@ -0,0 +1,80 @@
+// Package transfer encodes links for bulk export and import as JSON Lines
+// or CSV, one record per link, without holding the whole set in memory.
+package transfer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+type Format string
+
+const (
+	JSONL Format = "jsonl"
+	CSV   Format = "csv"
+)
+
+// ParseFormat accepts jsonl or csv, an empty value means jsonl.
+func ParseFormat(s string) (Format, error) {
+	switch format := Format(strings.ToLower(s)); format {
+	case "":
+		return JSONL, nil
+	case JSONL, CSV:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w: unknown format %q", models.ErrValidation, s)
+	}
+}
+
+func (f Format) ContentType() string {
+	if f == CSV {
+		return "text/csv"
+	}
+	return "application/x-ndjson"
+}
+
+// Policy decides what an import does with a record whose alias is taken.
+type Policy string
+
+const (
+	// Skip keeps the stored link and drops the record.
+	Skip Policy = "skip"
+	// Overwrite replaces the stored link, its revisions are dropped.
+	Overwrite Policy = "overwrite"
+	// Fail stops the import.
+	Fail Policy = "fail"
+)
+
+// ParsePolicy accepts skip, overwrite or fail, an empty value means fail.
+func ParsePolicy(s string) (Policy, error) {
+	switch policy := Policy(strings.ToLower(s)); policy {
+	case "":
+		return Fail, nil
+	case Skip, Overwrite, Fail:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("%w: unknown conflict policy %q", models.ErrValidation, s)
+	}
+}
+
+// Result counts the records of an import.
+type Result struct {
+	Imported int
+	// Skipped counts records dropped by the Skip policy.
+	Skipped int
+}
+
+// record is the stored form of a link, field names double as CSV columns.
+type record struct {
+	Alias     string     `json:"alias"`
+	URL       string     `json:"url"`
+	Owner     string     `json:"owner,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Disabled  bool       `json:"disabled,omitempty"`
+}
+
+var columns = []string{"alias", "url", "owner", "created_at", "expires_at", "disabled"}
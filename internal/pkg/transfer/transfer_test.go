@@ -0,0 +1,133 @@
+package transfer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+func TestRoundTrip(t *testing.T) {
+	createdAt := time.Date(2030, 1, 1, 12, 30, 0, 5000, time.UTC)
+	expiresAt := createdAt.Add(time.Hour)
+
+	links := []modellink.Link{
+		{URL: "https://example.com/?a=1,2", Alias: "abc", Owner: "grace", CreatedAt: createdAt, ExpiresAt: &expiresAt},
+		{URL: "https://example.org/\"quoted\"", Alias: "def", CreatedAt: createdAt, Disabled: true},
+	}
+
+	for _, format := range []Format{JSONL, CSV} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+
+			w := NewWriter(&buf, format)
+			for _, link := range links {
+				if err := w.Write(link); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatalf("Flush() error = %v", err)
+			}
+
+			r := NewReader(&buf, format)
+			for i, want := range links {
+				got, err := r.Read()
+				if err != nil {
+					t.Fatalf("Read() %d error = %v", i, err)
+				}
+
+				if got.URL != want.URL || got.Alias != want.Alias || got.Owner != want.Owner ||
+					got.Disabled != want.Disabled || !got.CreatedAt.Equal(want.CreatedAt) {
+					t.Errorf("Read() %d = %+v, want %+v", i, got, want)
+				}
+				if (got.ExpiresAt == nil) != (want.ExpiresAt == nil) ||
+					got.ExpiresAt != nil && !got.ExpiresAt.Equal(*want.ExpiresAt) {
+					t.Errorf("Read() %d expires_at = %v, want %v", i, got.ExpiresAt, want.ExpiresAt)
+				}
+			}
+
+			if _, err := r.Read(); !errors.Is(err, io.EOF) {
+				t.Errorf("expected io.EOF after the last record, got %v", err)
+			}
+		})
+	}
+}
+
+func TestReader_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		input  string
+	}{
+		{name: "json_malformed", format: JSONL, input: `{"alias":"abc",`},
+		{name: "json_unknown_field", format: JSONL, input: `{"alias":"abc","url":"https://a.com","hits":1}`},
+		{name: "json_trailing_data", format: JSONL, input: `{"alias":"abc","url":"https://a.com"} {}`},
+		{name: "json_missing_url", format: JSONL, input: `{"alias":"abc"}`},
+		{name: "json_bad_time", format: JSONL, input: `{"alias":"abc","url":"https://a.com","created_at":"yesterday"}`},
+		{name: "csv_no_header", format: CSV, input: ``},
+		{name: "csv_unknown_column", format: CSV, input: "alias,url,hits\nabc,https://a.com,1\n"},
+		{name: "csv_duplicate_column", format: CSV, input: "alias,url,url\nabc,https://a.com,https://b.com\n"},
+		{name: "csv_missing_alias_column", format: CSV, input: "url\nhttps://a.com\n"},
+		{name: "csv_wrong_field_count", format: CSV, input: "alias,url\nabc\n"},
+		{name: "csv_bad_expiry", format: CSV, input: "alias,url,expires_at\nabc,https://a.com,tomorrow\n"},
+		{name: "csv_bad_disabled", format: CSV, input: "alias,url,disabled\nabc,https://a.com,maybe\n"},
+		{name: "csv_empty_alias", format: CSV, input: "alias,url\n,https://a.com\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewReader(strings.NewReader(tt.input), tt.format).Read()
+			if !errors.Is(err, models.ErrValidation) {
+				t.Errorf("Read() error = %v, want %v", err, models.ErrValidation)
+			}
+		})
+	}
+}
+
+func TestReader_OptionalColumns(t *testing.T) {
+	r := NewReader(strings.NewReader("url,alias\nhttps://a.com,abc\n\n"), CSV)
+
+	link, err := r.Read()
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if link.Alias != "abc" || link.URL != "https://a.com" || !link.CreatedAt.IsZero() || link.ExpiresAt != nil {
+		t.Errorf("Read() = %+v", link)
+	}
+
+	r = NewReader(strings.NewReader("\n{\"alias\":\"abc\",\"url\":\"https://a.com\"}\n\n"), JSONL)
+	if _, err := r.Read(); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if _, err := r.Read(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected blank lines to be skipped, got %v", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	if format, err := ParseFormat(""); err != nil || format != JSONL {
+		t.Errorf("ParseFormat(\"\") = %q, %v", format, err)
+	}
+	if format, err := ParseFormat("CSV"); err != nil || format != CSV {
+		t.Errorf("ParseFormat(\"CSV\") = %q, %v", format, err)
+	}
+	if _, err := ParseFormat("xml"); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("ParseFormat(\"xml\") error = %v", err)
+	}
+
+	if policy, err := ParsePolicy(""); err != nil || policy != Fail {
+		t.Errorf("ParsePolicy(\"\") = %q, %v", policy, err)
+	}
+	if policy, err := ParsePolicy("overwrite"); err != nil || policy != Overwrite {
+		t.Errorf("ParsePolicy(\"overwrite\") = %q, %v", policy, err)
+	}
+	if _, err := ParsePolicy("merge"); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("ParsePolicy(\"merge\") error = %v", err)
+	}
+}
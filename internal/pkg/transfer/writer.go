@@ -0,0 +1,85 @@
+package transfer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+)
+
+// Writer encodes links one by one. Output is buffered, Flush must be
+// called after the last link.
+type Writer interface {
+	Write(link modellink.Link) error
+	Flush() error
+}
+
+func NewWriter(w io.Writer, format Format) Writer {
+	if format == CSV {
+		cw := csv.NewWriter(w)
+		cw.Write(columns)
+		return &csvWriter{w: cw}
+	}
+
+	bw := bufio.NewWriter(w)
+	return &jsonWriter{w: bw, encoder: json.NewEncoder(bw)}
+}
+
+func newRecord(link modellink.Link) record {
+	rec := record{
+		Alias:     link.Alias,
+		URL:       link.URL,
+		Owner:     link.Owner,
+		CreatedAt: link.CreatedAt.UTC(),
+		Disabled:  link.Disabled,
+	}
+	if link.ExpiresAt != nil {
+		expiresAt := link.ExpiresAt.UTC()
+		rec.ExpiresAt = &expiresAt
+	}
+	return rec
+}
+
+type jsonWriter struct {
+	w       *bufio.Writer
+	encoder *json.Encoder
+}
+
+func (w *jsonWriter) Write(link modellink.Link) error {
+	return w.encoder.Encode(newRecord(link))
+}
+
+func (w *jsonWriter) Flush() error {
+	return w.w.Flush()
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (w *csvWriter) Write(link modellink.Link) error {
+	rec := newRecord(link)
+
+	var expiresAt string
+	if rec.ExpiresAt != nil {
+		expiresAt = rec.ExpiresAt.Format(time.RFC3339Nano)
+	}
+
+	return w.w.Write([]string{
+		rec.Alias,
+		rec.URL,
+		rec.Owner,
+		rec.CreatedAt.Format(time.RFC3339Nano),
+		expiresAt,
+		strconv.FormatBool(rec.Disabled),
+	})
+}
+
+func (w *csvWriter) Flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"github.com/broadcast80/ozon-task/internal/pkg/metrics"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ExportPoolStats publishes pool statistics, they are sampled on scrape.
+func ExportPoolStats(registry *metrics.Registry, pool *pgxpool.Pool) {
+	registry.NewGaugeFunc("shortener_pgxpool_total_conns", "Connections currently in the pool.",
+		func() float64 { return float64(pool.Stat().TotalConns()) })
+	registry.NewGaugeFunc("shortener_pgxpool_acquired_conns", "Connections currently in use.",
+		func() float64 { return float64(pool.Stat().AcquiredConns()) })
+	registry.NewGaugeFunc("shortener_pgxpool_idle_conns", "Connections currently idle.",
+		func() float64 { return float64(pool.Stat().IdleConns()) })
+	registry.NewGaugeFunc("shortener_pgxpool_max_conns", "Maximum size of the pool.",
+		func() float64 { return float64(pool.Stat().MaxConns()) })
+	registry.NewCounterFunc("shortener_pgxpool_acquires_total", "Successful connection acquires.",
+		func() float64 { return float64(pool.Stat().AcquireCount()) })
+	registry.NewCounterFunc("shortener_pgxpool_empty_acquires_total", "Acquires that had to wait for a connection.",
+		func() float64 { return float64(pool.Stat().EmptyAcquireCount()) })
+	registry.NewCounterFunc("shortener_pgxpool_acquire_duration_seconds_total", "Time spent acquiring connections.",
+		func() float64 { return pool.Stat().AcquireDuration().Seconds() })
+}
@@ -0,0 +1,246 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+	"github.com/broadcast80/ozon-task/internal/usecase"
+	"golang.org/x/sync/singleflight"
+)
+
+// entry is a cached Get result. A nil link means the alias is known to be
+// missing.
+type entry struct {
+	alias     string
+	link      *modellink.Link
+	expiresAt time.Time
+}
+
+// repository is a read-through cache of alias lookups in front of another
+// repository. Writes go straight to the wrapped repository and invalidate
+// the affected alias.
+type repository struct {
+	next        usecase.RepositoryInterface
+	size        int
+	ttl         time.Duration
+	negativeTTL time.Duration
+
+	mu      sync.Mutex
+	items   map[string]*list.Element
+	order   *list.List
+	version uint64
+
+	group  singleflight.Group
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+func New(next usecase.RepositoryInterface, size int, ttl time.Duration, negativeTTL time.Duration) *repository {
+	return &repository{
+		next:        next,
+		size:        size,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		items:       make(map[string]*list.Element, size),
+		order:       list.New(),
+	}
+}
+
+// Stats returns the number of cache hits and misses since start.
+func (r *repository) Stats() (hits uint64, misses uint64) {
+	return r.hits.Load(), r.misses.Load()
+}
+
+func (r *repository) Create(ctx context.Context, link modellink.Link) error {
+	defer r.invalidate(link.Alias)
+	return r.next.Create(ctx, link)
+}
+
+func (r *repository) Get(ctx context.Context, alias string) (*modellink.Link, error) {
+	if link, ok := r.lookup(alias, time.Now()); ok {
+		r.hits.Add(1)
+		if link == nil {
+			return nil, models.ErrNotFound
+		}
+		return link, nil
+	}
+
+	r.misses.Add(1)
+
+	v, err, _ := r.group.Do(alias, func() (any, error) {
+		version := r.currentVersion()
+
+		// the result is shared, so a single caller going away must not fail the rest
+		link, err := r.next.Get(context.WithoutCancel(ctx), alias)
+		switch {
+		case err == nil:
+			r.store(alias, link, r.ttl, version)
+		case errors.Is(err, models.ErrNotFound):
+			r.store(alias, nil, r.negativeTTL, version)
+		}
+
+		return link, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	link := *v.(*modellink.Link)
+	return &link, nil
+}
+
+func (r *repository) URLExists(ctx context.Context, url string) (bool, error) {
+	return r.next.URLExists(ctx, url)
+}
+
+func (r *repository) GetAliasByURL(ctx context.Context, owner, url string) (string, error) {
+	return r.next.GetAliasByURL(ctx, owner, url)
+}
+
+func (r *repository) GetLinksByURL(ctx context.Context, owner string, urls []string) (map[string]modellink.Link, error) {
+	return r.next.GetLinksByURL(ctx, owner, urls)
+}
+
+func (r *repository) CreateBatch(ctx context.Context, links []modellink.Link) ([]error, error) {
+	defer func() {
+		for _, link := range links {
+			r.invalidate(link.Alias)
+		}
+	}()
+	return r.next.CreateBatch(ctx, links)
+}
+
+func (r *repository) Replace(ctx context.Context, links []modellink.Link) ([]error, error) {
+	defer func() {
+		for _, link := range links {
+			r.invalidate(link.Alias)
+		}
+	}()
+	return r.next.Replace(ctx, links)
+}
+
+func (r *repository) Delete(ctx context.Context, alias string) error {
+	defer r.invalidate(alias)
+	return r.next.Delete(ctx, alias)
+}
+
+func (r *repository) SetDisabled(ctx context.Context, alias string, disabled bool) error {
+	defer r.invalidate(alias)
+	return r.next.SetDisabled(ctx, alias, disabled)
+}
+
+func (r *repository) Update(ctx context.Context, alias, url string, at time.Time) (*modellink.Link, error) {
+	defer r.invalidate(alias)
+	return r.next.Update(ctx, alias, url, at)
+}
+
+func (r *repository) Revisions(ctx context.Context, alias string) ([]models.Revision, error) {
+	return r.next.Revisions(ctx, alias)
+}
+
+func (r *repository) List(ctx context.Context, filter modellink.Filter) (*modellink.Page, error) {
+	return r.next.List(ctx, filter)
+}
+
+func (r *repository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	removed, err := r.next.DeleteExpired(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for alias, el := range r.items {
+		if link := el.Value.(*entry).link; link != nil && link.Expired(now) {
+			r.remove(alias, el)
+		}
+	}
+	r.version++
+
+	return removed, nil
+}
+
+func (r *repository) lookup(alias string, now time.Time) (*modellink.Link, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	el, ok := r.items[alias]
+	if !ok {
+		return nil, false
+	}
+
+	e := el.Value.(*entry)
+	if !now.Before(e.expiresAt) {
+		r.remove(alias, el)
+		return nil, false
+	}
+
+	r.order.MoveToFront(el)
+
+	if e.link == nil {
+		return nil, true
+	}
+
+	link := *e.link
+	return &link, true
+}
+
+// store caches a lookup result unless a write happened since version was
+// taken, in which case the result may already be stale.
+func (r *repository) store(alias string, link *modellink.Link, ttl time.Duration, version uint64) {
+	if r.size <= 0 || ttl <= 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if version != r.version {
+		return
+	}
+
+	if el, ok := r.items[alias]; ok {
+		r.remove(alias, el)
+	}
+
+	r.items[alias] = r.order.PushFront(&entry{
+		alias:     alias,
+		link:      link,
+		expiresAt: time.Now().Add(ttl),
+	})
+
+	for r.order.Len() > r.size {
+		oldest := r.order.Back()
+		r.remove(oldest.Value.(*entry).alias, oldest)
+	}
+}
+
+func (r *repository) invalidate(alias string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if el, ok := r.items[alias]; ok {
+		r.remove(alias, el)
+	}
+	r.version++
+}
+
+func (r *repository) currentVersion() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.version
+}
+
+// remove drops a cached entry. The caller must hold r.mu.
+func (r *repository) remove(alias string, el *list.Element) {
+	r.order.Remove(el)
+	delete(r.items, alias)
+}
@@ -0,0 +1,290 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+type repoFake struct {
+	mu       sync.Mutex
+	links    map[string]modellink.Link
+	getCalls atomic.Int64
+	release  chan struct{}
+}
+
+func newRepoFake() *repoFake {
+	return &repoFake{links: make(map[string]modellink.Link)}
+}
+
+func (f *repoFake) Create(ctx context.Context, link modellink.Link) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.links[link.Alias]; ok {
+		return models.ErrDuplicate
+	}
+	f.links[link.Alias] = link
+	return nil
+}
+
+func (f *repoFake) Get(ctx context.Context, alias string) (*modellink.Link, error) {
+	f.getCalls.Add(1)
+	if f.release != nil {
+		<-f.release
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	link, ok := f.links[alias]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+	return &link, nil
+}
+
+func (f *repoFake) URLExists(ctx context.Context, url string) (bool, error) {
+	return false, nil
+}
+
+func (f *repoFake) GetAliasByURL(ctx context.Context, owner, url string) (string, error) {
+	return "", models.ErrNotFound
+}
+
+func (f *repoFake) GetLinksByURL(ctx context.Context, owner string, urls []string) (map[string]modellink.Link, error) {
+	return nil, nil
+}
+
+func (f *repoFake) CreateBatch(ctx context.Context, links []modellink.Link) ([]error, error) {
+	errs := make([]error, len(links))
+	for i, link := range links {
+		errs[i] = f.Create(ctx, link)
+	}
+	return errs, nil
+}
+
+func (f *repoFake) Replace(ctx context.Context, links []modellink.Link) ([]error, error) {
+	for _, link := range links {
+		f.Delete(ctx, link.Alias)
+	}
+	return f.CreateBatch(ctx, links)
+}
+
+func (f *repoFake) Delete(ctx context.Context, alias string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.links, alias)
+	return nil
+}
+
+func (f *repoFake) SetDisabled(ctx context.Context, alias string, disabled bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	link, ok := f.links[alias]
+	if !ok {
+		return models.ErrNotFound
+	}
+	link.Disabled = disabled
+	f.links[alias] = link
+	return nil
+}
+
+func (f *repoFake) Update(ctx context.Context, alias, url string, at time.Time) (*modellink.Link, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	link, ok := f.links[alias]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+	link.URL = url
+	f.links[alias] = link
+	return &link, nil
+}
+
+func (f *repoFake) Revisions(ctx context.Context, alias string) ([]models.Revision, error) {
+	return nil, nil
+}
+
+func (f *repoFake) List(ctx context.Context, filter modellink.Filter) (*modellink.Page, error) {
+	return &modellink.Page{}, nil
+}
+
+func (f *repoFake) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestRepository_Get_CachesHits(t *testing.T) {
+	next := newRepoFake()
+	next.Create(context.Background(), modellink.Link{URL: "https://example.com", Alias: "abc"})
+
+	r := New(next, 10, time.Minute, time.Minute)
+
+	for range 3 {
+		link, err := r.Get(context.Background(), "abc")
+		if err != nil {
+			t.Fatalf("Get() unexpected error: %v", err)
+		}
+		if link.URL != "https://example.com" {
+			t.Fatalf("Get() = %q, want %q", link.URL, "https://example.com")
+		}
+	}
+
+	if calls := next.getCalls.Load(); calls != 1 {
+		t.Errorf("wrapped Get calls = %d, want 1", calls)
+	}
+
+	if hits, misses := r.Stats(); hits != 2 || misses != 1 {
+		t.Errorf("Stats() = %d hits, %d misses, want 2 and 1", hits, misses)
+	}
+}
+
+func TestRepository_Get_NegativeCache(t *testing.T) {
+	next := newRepoFake()
+	r := New(next, 10, time.Minute, time.Minute)
+
+	for range 2 {
+		if _, err := r.Get(context.Background(), "missing"); !errors.Is(err, models.ErrNotFound) {
+			t.Fatalf("Get() error = %v, want %v", err, models.ErrNotFound)
+		}
+	}
+
+	if calls := next.getCalls.Load(); calls != 1 {
+		t.Errorf("wrapped Get calls = %d, want 1", calls)
+	}
+
+	if err := r.Create(context.Background(), modellink.Link{URL: "https://example.com", Alias: "missing"}); err != nil {
+		t.Fatalf("Create() unexpected error: %v", err)
+	}
+
+	if _, err := r.Get(context.Background(), "missing"); err != nil {
+		t.Errorf("Get() after Create unexpected error: %v", err)
+	}
+}
+
+func TestRepository_Delete_Invalidates(t *testing.T) {
+	next := newRepoFake()
+	next.Create(context.Background(), modellink.Link{URL: "https://example.com", Alias: "abc"})
+
+	r := New(next, 10, time.Minute, time.Minute)
+	r.Get(context.Background(), "abc")
+
+	r.Delete(context.Background(), "abc")
+
+	if _, err := r.Get(context.Background(), "abc"); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("Get() after Delete error = %v, want %v", err, models.ErrNotFound)
+	}
+}
+
+func TestRepository_SetDisabled_Invalidates(t *testing.T) {
+	next := newRepoFake()
+	next.Create(context.Background(), modellink.Link{URL: "https://example.com", Alias: "abc"})
+
+	r := New(next, 10, time.Minute, time.Minute)
+	r.Get(context.Background(), "abc")
+
+	if err := r.SetDisabled(context.Background(), "abc", true); err != nil {
+		t.Fatalf("SetDisabled() unexpected error: %v", err)
+	}
+
+	link, err := r.Get(context.Background(), "abc")
+	if err != nil || !link.Disabled {
+		t.Errorf("Get() after SetDisabled = %+v, %v, want disabled link", link, err)
+	}
+}
+
+func TestRepository_Update_Invalidates(t *testing.T) {
+	next := newRepoFake()
+	next.Create(context.Background(), modellink.Link{URL: "https://example.com", Alias: "abc"})
+
+	r := New(next, 10, time.Minute, time.Minute)
+	r.Get(context.Background(), "abc")
+
+	if _, err := r.Update(context.Background(), "abc", "https://example.org", time.Now()); err != nil {
+		t.Fatalf("Update() unexpected error: %v", err)
+	}
+
+	link, err := r.Get(context.Background(), "abc")
+	if err != nil || link.URL != "https://example.org" {
+		t.Errorf("Get() after Update = %+v, %v, want the new URL", link, err)
+	}
+}
+
+func TestRepository_TTL(t *testing.T) {
+	next := newRepoFake()
+	next.Create(context.Background(), modellink.Link{URL: "https://example.com", Alias: "abc"})
+
+	r := New(next, 10, time.Millisecond, time.Millisecond)
+	r.Get(context.Background(), "abc")
+
+	time.Sleep(5 * time.Millisecond)
+	r.Get(context.Background(), "abc")
+
+	if calls := next.getCalls.Load(); calls != 2 {
+		t.Errorf("wrapped Get calls = %d, want 2", calls)
+	}
+}
+
+func TestRepository_EvictsLeastRecentlyUsed(t *testing.T) {
+	next := newRepoFake()
+	for _, alias := range []string{"a", "b", "c"} {
+		next.Create(context.Background(), modellink.Link{URL: "https://" + alias + ".com", Alias: alias})
+	}
+
+	r := New(next, 2, time.Minute, time.Minute)
+	r.Get(context.Background(), "a")
+	r.Get(context.Background(), "b")
+	r.Get(context.Background(), "a")
+	r.Get(context.Background(), "c")
+
+	next.getCalls.Store(0)
+
+	r.Get(context.Background(), "a")
+	if calls := next.getCalls.Load(); calls != 0 {
+		t.Errorf("recently used alias was evicted")
+	}
+
+	r.Get(context.Background(), "b")
+	if calls := next.getCalls.Load(); calls != 1 {
+		t.Errorf("least recently used alias was not evicted")
+	}
+}
+
+func TestRepository_Get_CollapsesConcurrentMisses(t *testing.T) {
+	next := newRepoFake()
+	next.Create(context.Background(), modellink.Link{URL: "https://example.com", Alias: "abc"})
+	next.release = make(chan struct{})
+
+	r := New(next, 10, time.Minute, time.Minute)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := r.Get(context.Background(), "abc"); err != nil {
+				t.Errorf("Get() unexpected error: %v", err)
+			}
+		}()
+	}
+
+	for next.getCalls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(next.release)
+	wg.Wait()
+
+	if calls := next.getCalls.Load(); calls != 1 {
+		t.Errorf("wrapped Get calls = %d, want 1", calls)
+	}
+}
@@ -0,0 +1,52 @@
+package inmemory
+
+import "fmt"
+
+// EvictionPolicy decides what happens when a Create would exceed the
+// store size.
+type EvictionPolicy string
+
+const (
+	// EvictionReject refuses new links with models.ErrStorageFull.
+	EvictionReject EvictionPolicy = "reject"
+	// EvictionLRU drops the least recently created or resolved link.
+	EvictionLRU EvictionPolicy = "lru"
+	// EvictionOldest drops the earliest created link.
+	EvictionOldest EvictionPolicy = "oldest"
+)
+
+func ParseEvictionPolicy(s string) (EvictionPolicy, error) {
+	switch policy := EvictionPolicy(s); policy {
+	case EvictionReject, EvictionLRU, EvictionOldest:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown eviction policy: %s", s)
+	}
+}
+
+// Evictions returns how many links were evicted to stay within the size.
+func (r *repository) Evictions() uint64 {
+	return r.evictions.Load()
+}
+
+// full reports whether one more link would exceed the size.
+// The caller must hold r.mu.
+func (r *repository) full() bool {
+	return r.size > 0 && len(r.aliasToURL) >= r.size
+}
+
+// evict drops the link at the back of the order list.
+// The caller must hold r.mu.
+func (r *repository) evict() error {
+	oldest := r.order.Back()
+	if oldest == nil {
+		return nil
+	}
+
+	if err := r.delete(oldest.Value.(string)); err != nil {
+		return err
+	}
+	r.evictions.Add(1)
+
+	return nil
+}
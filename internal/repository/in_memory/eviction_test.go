@@ -0,0 +1,93 @@
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+func TestRepository_Eviction(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        EvictionPolicy
+		wantErr       error
+		wantKept      []string
+		wantEvicted   []string
+		wantEvictions uint64
+	}{
+		{
+			name:        "reject",
+			policy:      EvictionReject,
+			wantErr:     models.ErrStorageFull,
+			wantKept:    []string{"a", "b"},
+			wantEvicted: []string{"c"},
+		},
+		{
+			name:          "lru",
+			policy:        EvictionLRU,
+			wantKept:      []string{"a", "c"},
+			wantEvicted:   []string{"b"},
+			wantEvictions: 1,
+		},
+		{
+			name:          "oldest",
+			policy:        EvictionOldest,
+			wantKept:      []string{"b", "c"},
+			wantEvicted:   []string{"a"},
+			wantEvictions: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			r := New(2, tt.policy)
+
+			r.Create(ctx, modellink.Link{URL: "https://a.com", Alias: "a"})
+			r.Create(ctx, modellink.Link{URL: "https://b.com", Alias: "b"})
+			r.Get(ctx, "a")
+
+			err := r.Create(ctx, modellink.Link{URL: "https://c.com", Alias: "c"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			for _, alias := range tt.wantKept {
+				if _, err := r.Get(ctx, alias); err != nil {
+					t.Errorf("Get(%q) unexpected error: %v", alias, err)
+				}
+			}
+
+			for _, alias := range tt.wantEvicted {
+				if _, err := r.Get(ctx, alias); !errors.Is(err, models.ErrNotFound) {
+					t.Errorf("Get(%q) error = %v, want %v", alias, err, models.ErrNotFound)
+				}
+				if exists, _ := r.URLExists(ctx, "https://"+alias+".com"); exists {
+					t.Errorf("URLExists(%q) returned true for an evicted link", alias)
+				}
+			}
+
+			if got := r.Evictions(); got != tt.wantEvictions {
+				t.Errorf("Evictions() = %d, want %d", got, tt.wantEvictions)
+			}
+
+			if len(r.aliasToURL) != len(r.urlToAlias) || len(r.aliasToURL) != r.order.Len() {
+				t.Errorf("indexes out of sync: %d aliases, %d urls, %d ordered",
+					len(r.aliasToURL), len(r.urlToAlias), r.order.Len())
+			}
+		})
+	}
+}
+
+func TestParseEvictionPolicy(t *testing.T) {
+	if _, err := ParseEvictionPolicy("lru"); err != nil {
+		t.Errorf("ParseEvictionPolicy(lru) unexpected error: %v", err)
+	}
+
+	if _, err := ParseEvictionPolicy("random"); err == nil {
+		t.Error("ParseEvictionPolicy(random) expected error")
+	}
+}
@@ -0,0 +1,102 @@
+package inmemory
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"time"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+)
+
+// Records in the journal and in snapshots share one framing:
+//
+//	uint32 payload length | uint32 CRC-32 (IEEE) of payload | JSON payload
+//
+// all integers big-endian.
+const (
+	headerSize    = 8
+	maxRecordSize = 1 << 20
+
+	opCreate      = "create"
+	opDelete      = "delete"
+	opSetDisabled = "set_disabled"
+	opUpdate      = "update"
+	opReplace     = "replace"
+)
+
+var errCorruptRecord = errors.New("corrupt record")
+
+type record struct {
+	Op   string         `json:"op"`
+	Link modellink.Link `json:"link"`
+	// At and Revision are set for updates only: when the previous URL was
+	// replaced and how many revisions the alias has after the update.
+	At       time.Time `json:"at,omitzero"`
+	Revision int       `json:"revision,omitempty"`
+}
+
+func writeRecord(w io.Writer, rec record) error {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshal record: %w", err)
+	}
+
+	buf := make([]byte, headerSize+len(payload))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(payload))
+	copy(buf[headerSize:], payload)
+
+	_, err = w.Write(buf)
+	return err
+}
+
+// readRecords calls apply for every intact record in r and returns the
+// offset just past the last one. A truncated or corrupted record stops the
+// scan with errCorruptRecord, io.EOF at a record boundary is not an error.
+func readRecords(r io.Reader, apply func(rec record)) (int64, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, headerSize)
+
+	var offset int64
+	for {
+		if _, err := io.ReadFull(br, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return offset, nil
+			}
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				return offset, fmt.Errorf("%w: truncated header at offset %d", errCorruptRecord, offset)
+			}
+			return offset, err
+		}
+
+		size := binary.BigEndian.Uint32(header[0:4])
+		if size > maxRecordSize {
+			return offset, fmt.Errorf("%w: record of %d bytes at offset %d", errCorruptRecord, size, offset)
+		}
+
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(br, payload); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return offset, fmt.Errorf("%w: truncated payload at offset %d", errCorruptRecord, offset)
+			}
+			return offset, err
+		}
+
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+			return offset, fmt.Errorf("%w: checksum mismatch at offset %d", errCorruptRecord, offset)
+		}
+
+		var rec record
+		if err := json.Unmarshal(payload, &rec); err != nil {
+			return offset, fmt.Errorf("%w: %s at offset %d", errCorruptRecord, err.Error(), offset)
+		}
+
+		apply(rec)
+		offset += int64(headerSize) + int64(size)
+	}
+}
@@ -0,0 +1,136 @@
+package inmemory
+
+import (
+	"cmp"
+	"context"
+	"net/url"
+	"slices"
+	"strings"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+)
+
+// List walks the creation index under a single read lock, so a page never
+// mixes states. Pages are cut by (CreatedAt, Alias), which keeps pagination
+// stable while links are added or removed between calls. The walk starts at
+// the cursor and stops once the page is full.
+func (r *repository) List(ctx context.Context, filter modellink.Filter) (*modellink.Page, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var links []modellink.Link
+	start, step := r.seek(filter)
+	for i := start; i >= 0 && i < len(r.created) && !pastRange(r.created[i], filter); i += step {
+		link := r.aliasToURL[r.created[i].Alias]
+		if !matches(link, filter) {
+			continue
+		}
+
+		links = append(links, link)
+		if filter.Limit > 0 && len(links) > filter.Limit {
+			break
+		}
+	}
+
+	page := &modellink.Page{Links: links}
+	if filter.Limit > 0 && len(links) > filter.Limit {
+		page.Links = links[:filter.Limit]
+		last := page.Links[filter.Limit-1]
+		page.Next = &modellink.Cursor{CreatedAt: last.CreatedAt, Alias: last.Alias}
+	}
+
+	return page, nil
+}
+
+// seek returns the position in r.created the listing starts at and the
+// direction to walk in. The caller must hold r.mu.
+func (r *repository) seek(filter modellink.Filter) (int, int) {
+	if filter.Ascending {
+		start := 0
+		if !filter.CreatedAfter.IsZero() {
+			start = r.search(modellink.Cursor{CreatedAt: filter.CreatedAfter})
+		}
+		if filter.After != nil {
+			position := r.search(*filter.After)
+			if position < len(r.created) && compareCursors(r.created[position], *filter.After) == 0 {
+				position++
+			}
+			start = max(start, position)
+		}
+		return start, 1
+	}
+
+	start := len(r.created) - 1
+	if !filter.CreatedBefore.IsZero() {
+		start = r.search(modellink.Cursor{CreatedAt: filter.CreatedBefore}) - 1
+	}
+	if filter.After != nil {
+		start = min(start, r.search(*filter.After)-1)
+	}
+	return start, -1
+}
+
+// search returns the position of the first link not before cursor. The
+// caller must hold r.mu.
+func (r *repository) search(cursor modellink.Cursor) int {
+	position, _ := slices.BinarySearchFunc(r.created, cursor, compareCursors)
+	return position
+}
+
+// pastRange reports whether the walk has left the creation time range of
+// filter, no later link can match.
+func pastRange(key modellink.Cursor, filter modellink.Filter) bool {
+	if filter.Ascending {
+		return !filter.CreatedBefore.IsZero() && !key.CreatedAt.Before(filter.CreatedBefore)
+	}
+	return !filter.CreatedAfter.IsZero() && key.CreatedAt.Before(filter.CreatedAfter)
+}
+
+// addCreated inserts link into the creation index. The caller must hold
+// r.mu.
+func (r *repository) addCreated(link modellink.Link) {
+	key := modellink.Cursor{CreatedAt: link.CreatedAt, Alias: link.Alias}
+	// links mostly arrive in creation order, so this is usually an append
+	r.created = slices.Insert(r.created, r.search(key), key)
+}
+
+// removeCreated drops link from the creation index. The caller must hold
+// r.mu.
+func (r *repository) removeCreated(link modellink.Link) {
+	key := modellink.Cursor{CreatedAt: link.CreatedAt, Alias: link.Alias}
+	if position, ok := slices.BinarySearchFunc(r.created, key, compareCursors); ok {
+		r.created = slices.Delete(r.created, position, position+1)
+	}
+}
+
+func compareCursors(a, b modellink.Cursor) int {
+	return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), strings.Compare(a.Alias, b.Alias))
+}
+
+func matches(link modellink.Link, filter modellink.Filter) bool {
+	switch {
+	case filter.Owner != "" && link.Owner != filter.Owner:
+		return false
+	case !filter.CreatedAfter.IsZero() && link.CreatedAt.Before(filter.CreatedAfter):
+		return false
+	case !filter.CreatedBefore.IsZero() && !link.CreatedAt.Before(filter.CreatedBefore):
+		return false
+	case filter.URLContains != "" && !strings.Contains(link.URL, filter.URLContains):
+		return false
+	case filter.Domain != "" && !inDomain(link.URL, filter.Domain):
+		return false
+	}
+
+	return true
+}
+
+// inDomain reports whether the host of rawURL is domain or its subdomain.
+func inDomain(rawURL, domain string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	host := u.Hostname()
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
@@ -0,0 +1,154 @@
+package inmemory
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+)
+
+func aliases(links []modellink.Link) []string {
+	result := make([]string, 0, len(links))
+	for _, link := range links {
+		result = append(result, link.Alias)
+	}
+	return result
+}
+
+func TestRepository_List_Filters(t *testing.T) {
+	ctx := context.Background()
+	r := New(10, EvictionReject)
+
+	base := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, link := range []modellink.Link{
+		{URL: "https://dogville.com/", Alias: "a", Owner: "grace"},
+		{URL: "https://shop.dogville.com/sale", Alias: "b", Owner: "grace"},
+		{URL: "https://notdogville.com/", Alias: "c", Owner: "tom"},
+		{URL: "https://manderlay.com/sale", Alias: "d", Owner: "tom"},
+	} {
+		link.CreatedAt = base.Add(time.Duration(i) * time.Hour)
+		r.Create(ctx, link)
+	}
+
+	tests := []struct {
+		name   string
+		filter modellink.Filter
+		want   []string
+	}{
+		{name: "all_newest_first", filter: modellink.Filter{}, want: []string{"d", "c", "b", "a"}},
+		{name: "ascending", filter: modellink.Filter{Ascending: true}, want: []string{"a", "b", "c", "d"}},
+		{name: "owner", filter: modellink.Filter{Owner: "tom"}, want: []string{"d", "c"}},
+		{name: "created_after", filter: modellink.Filter{CreatedAfter: base.Add(2 * time.Hour)}, want: []string{"d", "c"}},
+		{name: "created_before", filter: modellink.Filter{CreatedBefore: base.Add(time.Hour)}, want: []string{"a"}},
+		{name: "url_contains", filter: modellink.Filter{URLContains: "/sale"}, want: []string{"d", "b"}},
+		{name: "domain", filter: modellink.Filter{Domain: "dogville.com"}, want: []string{"b", "a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := r.List(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("List() unexpected error: %v", err)
+			}
+			if got := aliases(page.Links); !slices.Equal(got, tt.want) {
+				t.Errorf("List() = %v, want %v", got, tt.want)
+			}
+			if page.Next != nil {
+				t.Errorf("List() without limit returned a next cursor")
+			}
+		})
+	}
+}
+
+func TestRepository_List_Pagination(t *testing.T) {
+	ctx := context.Background()
+	r := New(0, EvictionReject)
+
+	// equal creation times are ordered by alias
+	at := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, alias := range []string{"a", "b", "c", "d", "e"} {
+		r.Create(ctx, modellink.Link{URL: "https://dogville.com/" + alias, Alias: alias, CreatedAt: at})
+	}
+
+	filter := modellink.Filter{Ascending: true, Limit: 2}
+
+	var got []string
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatalf("pagination did not stop")
+		}
+
+		page, err := r.List(ctx, filter)
+		if err != nil {
+			t.Fatalf("List() unexpected error: %v", err)
+		}
+		got = append(got, aliases(page.Links)...)
+
+		if pages == 0 {
+			// changes between pages neither repeat nor skip links
+			r.Delete(ctx, "a")
+			r.Create(ctx, modellink.Link{URL: "https://dogville.com/f", Alias: "f", CreatedAt: at.Add(time.Hour)})
+		}
+
+		if page.Next == nil {
+			break
+		}
+		filter.After = page.Next
+	}
+
+	want := []string{"a", "b", "c", "d", "e", "f"}
+	if !slices.Equal(got, want) {
+		t.Errorf("pages = %v, want %v", got, want)
+	}
+}
+
+func TestRepository_List_PaginationNewestFirst(t *testing.T) {
+	ctx := context.Background()
+	r := New(0, EvictionReject)
+
+	base := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	// created out of order, with a tie broken by alias
+	for _, link := range []modellink.Link{
+		{Alias: "c", CreatedAt: base.Add(2 * time.Hour)},
+		{Alias: "a", CreatedAt: base},
+		{Alias: "e", CreatedAt: base.Add(3 * time.Hour)},
+		{Alias: "b", CreatedAt: base.Add(2 * time.Hour)},
+		{Alias: "d", CreatedAt: base.Add(4 * time.Hour)},
+		{Alias: "x", CreatedAt: base.Add(5 * time.Hour), Owner: "tom"},
+	} {
+		link.URL = "https://dogville.com/" + link.Alias
+		r.Create(ctx, link)
+	}
+	r.Delete(ctx, "d")
+
+	filter := modellink.Filter{
+		CreatedAfter:  base.Add(time.Hour),
+		CreatedBefore: base.Add(5 * time.Hour),
+		Limit:         2,
+	}
+
+	var got []string
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatalf("pagination did not stop")
+		}
+
+		page, err := r.List(ctx, filter)
+		if err != nil {
+			t.Fatalf("List() unexpected error: %v", err)
+		}
+		got = append(got, aliases(page.Links)...)
+
+		if page.Next == nil {
+			break
+		}
+		filter.After = page.Next
+	}
+
+	want := []string{"e", "c", "b"}
+	if !slices.Equal(got, want) {
+		t.Errorf("pages = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !unix
+
+package inmemory
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// lockDir only creates the lock file, there is no flock to guard dir with
+// on this platform.
+func lockDir(dir string) (*os.File, error) {
+	file, err := os.OpenFile(filepath.Join(dir, lockFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open lock file: %w", err)
+	}
+	return file, nil
+}
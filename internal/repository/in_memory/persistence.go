@@ -0,0 +1,384 @@
+package inmemory
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+const (
+	snapshotFile = "snapshot.db"
+	journalFile  = "journal.log"
+	lockFile     = "lock"
+)
+
+// ErrDirLocked is returned by NewPersistent when another process holds the
+// persistence directory.
+var ErrDirLocked = errors.New("persistence dir is used by another process")
+
+// journal is the append-only log of changes made since the last snapshot.
+type journal struct {
+	dir   string
+	file  *os.File
+	fsync bool
+	// lock holds dir for this process until Close
+	lock *os.File
+
+	// snapshotMu serializes snapshots, the store lock is released while
+	// one is written
+	snapshotMu sync.Mutex
+
+	stop chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+func (j *journal) append(rec record) error {
+	if err := writeRecord(j.file, rec); err != nil {
+		return fmt.Errorf("append to journal: %w", err)
+	}
+
+	if j.fsync {
+		return j.file.Sync()
+	}
+
+	return nil
+}
+
+// NewPersistent restores a store from the snapshot and journal in dir and
+// journals every following change there. A corrupted or truncated journal
+// tail is logged and cut off, everything before it is kept. The directory
+// is locked until Close, a second process gets ErrDirLocked.
+func NewPersistent(storeSize int, policy EvictionPolicy, dir string, fsync bool, logger *slog.Logger) (_ *repository, err error) {
+	r := New(storeSize, policy)
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create persistence dir: %w", err)
+	}
+
+	lock, err := lockDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			lock.Close()
+		}
+	}()
+
+	if err := r.loadSnapshot(filepath.Join(dir, snapshotFile)); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, journalFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open journal: %w", err)
+	}
+
+	offset, err := readRecords(file, r.apply)
+	if errors.Is(err, errCorruptRecord) {
+		logger.Warn("discarding corrupted journal tail", "offset", offset, "Error", err.Error())
+		if err := file.Truncate(offset); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("truncate journal: %w", err)
+		}
+	} else if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("replay journal: %w", err)
+	}
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("seek journal: %w", err)
+	}
+
+	r.journal = &journal{
+		dir:   dir,
+		file:  file,
+		fsync: fsync,
+		lock:  lock,
+		stop:  make(chan struct{}),
+	}
+
+	logger.Info("restored in-memory storage", "links", len(r.aliasToURL))
+
+	return r, nil
+}
+
+// StartSnapshots compacts the journal into a snapshot every interval until
+// Close is called.
+func (r *repository) StartSnapshots(interval time.Duration, logger *slog.Logger) {
+	j := r.journal
+	if j == nil {
+		return
+	}
+
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-j.stop:
+				return
+			case <-ticker.C:
+				if err := r.Snapshot(); err != nil {
+					logger.Error("failed to snapshot in-memory storage", "Error", err.Error())
+				}
+			}
+		}
+	}()
+}
+
+// snapshotEntry is a link with its revisions, copied out of the store.
+type snapshotEntry struct {
+	link    modellink.Link
+	history []models.Revision
+}
+
+// Snapshot atomically writes the whole store to the snapshot file and
+// drops the journal records it covers. The store is only locked to copy it
+// and to swap the journal, writes go on while the snapshot is written.
+func (r *repository) Snapshot() error {
+	j := r.journal
+	if j == nil {
+		return nil
+	}
+
+	j.snapshotMu.Lock()
+	defer j.snapshotMu.Unlock()
+
+	entries, offset, err := r.snapshotEntries()
+	if err != nil {
+		return err
+	}
+
+	if err := writeSnapshot(j.dir, entries); err != nil {
+		return err
+	}
+
+	// replaying the old journal over the new snapshot is harmless, so a
+	// crash before the journal is swapped loses nothing
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return j.compact(offset)
+}
+
+// snapshotEntries copies the store, oldest first so replay rebuilds the
+// eviction order, and returns the journal offset the copy reflects.
+func (r *repository) snapshotEntries() ([]snapshotEntry, int64, error) {
+	// appends happen under the write lock, so the offset matches the copy
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	offset, err := r.journal.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, 0, fmt.Errorf("seek journal: %w", err)
+	}
+
+	entries := make([]snapshotEntry, 0, len(r.aliasToURL))
+	for el := r.order.Back(); el != nil; el = el.Prev() {
+		alias := el.Value.(string)
+		entries = append(entries, snapshotEntry{
+			link:    r.aliasToURL[alias],
+			history: slices.Clone(r.revisions[alias]),
+		})
+	}
+
+	return entries, offset, nil
+}
+
+// writeSnapshot atomically replaces the snapshot file in dir with entries.
+func writeSnapshot(dir string, entries []snapshotEntry) error {
+	path := filepath.Join(dir, snapshotFile)
+	tmp := path + ".tmp"
+
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("create snapshot: %w", err)
+	}
+
+	w := bufio.NewWriter(file)
+	for _, entry := range entries {
+		if err := writeLink(w, entry.link, entry.history); err != nil {
+			file.Close()
+			return fmt.Errorf("write snapshot: %w", err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("sync snapshot: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close snapshot: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("replace snapshot: %w", err)
+	}
+	syncDir(dir)
+
+	return nil
+}
+
+// compact replaces the journal with its records past offset, the ones
+// written while the snapshot was. The caller must hold r.mu.
+func (j *journal) compact(offset int64) error {
+	end, err := j.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("seek journal: %w", err)
+	}
+
+	path := filepath.Join(j.dir, journalFile)
+	tmp := path + ".tmp"
+
+	file, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("create journal: %w", err)
+	}
+
+	if _, err := io.Copy(file, io.NewSectionReader(j.file, offset, end-offset)); err != nil {
+		file.Close()
+		return fmt.Errorf("copy journal tail: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("sync journal: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		file.Close()
+		return fmt.Errorf("replace journal: %w", err)
+	}
+	syncDir(j.dir)
+
+	j.file.Close()
+	j.file = file
+
+	return nil
+}
+
+func syncDir(path string) {
+	if dir, err := os.Open(path); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+}
+
+// writeLink writes link as it was created followed by its updates, so
+// replaying them restores the revision history too.
+func writeLink(w io.Writer, link modellink.Link, history []models.Revision) error {
+	created := link
+	if len(history) > 0 {
+		created.URL = history[0].URL
+	}
+	if err := writeRecord(w, record{Op: opCreate, Link: created}); err != nil {
+		return err
+	}
+
+	for i, revision := range history {
+		url := link.URL
+		if i+1 < len(history) {
+			url = history[i+1].URL
+		}
+
+		rec := record{
+			Op:       opUpdate,
+			Link:     modellink.Link{Alias: link.Alias, URL: url},
+			At:       revision.ReplacedAt,
+			Revision: i + 1,
+		}
+		if err := writeRecord(w, rec); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close stops periodic snapshots, writes a final one, closes the journal
+// and releases the directory.
+func (r *repository) Close() error {
+	j := r.journal
+	if j == nil {
+		return nil
+	}
+
+	j.once.Do(func() { close(j.stop) })
+	j.wg.Wait()
+
+	defer j.lock.Close()
+
+	if err := r.Snapshot(); err != nil {
+		j.file.Close()
+		return err
+	}
+
+	return j.file.Close()
+}
+
+func (r *repository) loadSnapshot(path string) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("open snapshot: %w", err)
+	}
+	defer file.Close()
+
+	// snapshots are replaced atomically, a damaged one is not a crash artifact
+	if _, err := readRecords(file, r.apply); err != nil {
+		return fmt.Errorf("load snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// apply replays a record. Records that are already reflected in the store
+// are skipped or, for replaces, redone together with the records after
+// them, so the journal may safely overlap the snapshot.
+func (r *repository) apply(rec record) {
+	switch rec.Op {
+	case opCreate:
+		if _, ok := r.aliasToURL[rec.Link.Alias]; !ok {
+			r.put(rec.Link)
+		}
+	case opDelete:
+		if _, ok := r.aliasToURL[rec.Link.Alias]; ok {
+			r.remove(rec.Link.Alias)
+		}
+	case opSetDisabled:
+		if _, ok := r.aliasToURL[rec.Link.Alias]; ok {
+			r.setDisabled(rec.Link.Alias, rec.Link.Disabled)
+		}
+	case opReplace:
+		if _, ok := r.aliasToURL[rec.Link.Alias]; ok {
+			r.remove(rec.Link.Alias)
+		}
+		r.put(rec.Link)
+	case opUpdate:
+		_, ok := r.aliasToURL[rec.Link.Alias]
+		if ok && len(r.revisions[rec.Link.Alias]) < rec.Revision {
+			r.update(rec.Link.Alias, rec.Link.URL, rec.At)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func openPersistent(t *testing.T, dir string) *repository {
+	t.Helper()
+
+	r, err := NewPersistent(10, EvictionOldest, dir, false, testLogger())
+	if err != nil {
+		t.Fatalf("NewPersistent() unexpected error: %v", err)
+	}
+	return r
+}
+
+// crash drops r like a killed process would: the journal is closed without
+// a final snapshot and the directory lock is released.
+func crash(r *repository) {
+	r.journal.file.Close()
+	r.journal.lock.Close()
+}
+
+func TestPersistence_ReplaysJournal(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	r := openPersistent(t, dir)
+	r.Create(ctx, modellink.Link{URL: "https://a.com", Alias: "a", ExpiresAt: &expiresAt, Owner: "grace"})
+	r.Create(ctx, modellink.Link{URL: "https://b.com", Alias: "b"})
+	r.Delete(ctx, "b")
+	r.Create(ctx, modellink.Link{URL: "https://c.com", Alias: "c"})
+	r.SetDisabled(ctx, "c", true)
+	r.Create(ctx, modellink.Link{URL: "https://d.com", Alias: "d"})
+	r.Replace(ctx, []modellink.Link{{URL: "https://e.com", Alias: "d", Owner: "tom"}})
+	// no Close: simulate a crash, only the journal is on disk
+	crash(r)
+
+	restored := openPersistent(t, dir)
+	defer restored.Close()
+
+	link, err := restored.Get(ctx, "a")
+	if err != nil {
+		t.Fatalf("Get(a) unexpected error: %v", err)
+	}
+	if link.URL != "https://a.com" || link.ExpiresAt == nil || !link.ExpiresAt.Equal(expiresAt) || link.Owner != "grace" {
+		t.Errorf("Get(a) = %+v, want URL, expiry and owner restored", link)
+	}
+
+	if _, err := restored.Get(ctx, "b"); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("Get(b) error = %v, want %v", err, models.ErrNotFound)
+	}
+
+	if link, err := restored.Get(ctx, "c"); err != nil || !link.Disabled {
+		t.Errorf("Get(c) = %+v, %v, want disabled link", link, err)
+	}
+	if _, err := restored.GetAliasByURL(ctx, "", "https://c.com"); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("GetAliasByURL(c) error = %v, want %v", err, models.ErrNotFound)
+	}
+
+	if link, err := restored.Get(ctx, "d"); err != nil || link.URL != "https://e.com" || link.Owner != "tom" {
+		t.Errorf("Get(d) = %+v, %v, want the replacing link", link, err)
+	}
+}
+
+func TestPersistence_ReplaceKeepsLinkOnFailure(t *testing.T) {
+	ctx := context.Background()
+
+	r := openPersistent(t, t.TempDir())
+	defer r.journal.lock.Close()
+
+	r.Create(ctx, modellink.Link{URL: "https://dogville.com", Alias: "abc", Owner: "grace"})
+	// every following journal write fails
+	r.journal.file.Close()
+
+	errs, err := r.Replace(ctx, []modellink.Link{{URL: "https://manderlay.com", Alias: "abc", Owner: "tom"}})
+	if err != nil || errs[0] == nil {
+		t.Fatalf("Replace() = %v, %v, want a failed record", errs, err)
+	}
+
+	if link, err := r.Get(ctx, "abc"); err != nil || link.URL != "https://dogville.com" || link.Owner != "grace" {
+		t.Errorf("Get() = %+v, %v, want the original link", link, err)
+	}
+}
+
+func TestPersistence_RestoresRevisions(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	at := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	r := openPersistent(t, dir)
+	r.Create(ctx, modellink.Link{URL: "https://a.com", Alias: "a"})
+	r.Update(ctx, "a", "https://b.com", at)
+	r.Update(ctx, "a", "https://c.com", at.Add(time.Hour))
+
+	stale, err := os.ReadFile(filepath.Join(dir, journalFile))
+	if err != nil {
+		t.Fatalf("read journal: %v", err)
+	}
+
+	if err := r.Snapshot(); err != nil {
+		t.Fatalf("Snapshot() unexpected error: %v", err)
+	}
+	r.Update(ctx, "a", "https://d.com", at.Add(2*time.Hour))
+	crash(r)
+
+	// a crash between the snapshot and the journal truncation leaves the
+	// old records in front of the new ones
+	journal, err := os.ReadFile(filepath.Join(dir, journalFile))
+	if err != nil {
+		t.Fatalf("read journal: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, journalFile), append(stale, journal...), 0o644); err != nil {
+		t.Fatalf("write journal: %v", err)
+	}
+
+	restored := openPersistent(t, dir)
+	defer restored.Close()
+
+	link, err := restored.Get(ctx, "a")
+	if err != nil || link.URL != "https://d.com" {
+		t.Fatalf("Get(a) = %+v, %v, want https://d.com", link, err)
+	}
+
+	revisions, err := restored.Revisions(ctx, "a")
+	if err != nil {
+		t.Fatalf("Revisions(a) unexpected error: %v", err)
+	}
+	want := []string{"https://a.com", "https://b.com", "https://c.com"}
+	if len(revisions) != len(want) {
+		t.Fatalf("Revisions(a) = %+v, want URLs %v", revisions, want)
+	}
+	for i, revision := range revisions {
+		if revision.URL != want[i] || !revision.ReplacedAt.Equal(at.Add(time.Duration(i)*time.Hour)) {
+			t.Errorf("revision %d = %+v, want %s replaced at +%dh", i, revision, want[i], i)
+		}
+	}
+
+	if alias, err := restored.GetAliasByURL(ctx, "", "https://d.com"); err != nil || alias != "a" {
+		t.Errorf("GetAliasByURL(d) = %q, %v, want a", alias, err)
+	}
+}
+
+func TestPersistence_SnapshotCompactsJournal(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	r := openPersistent(t, dir)
+	r.Create(ctx, modellink.Link{URL: "https://a.com", Alias: "a"})
+	r.Create(ctx, modellink.Link{URL: "https://b.com", Alias: "b"})
+
+	if err := r.Snapshot(); err != nil {
+		t.Fatalf("Snapshot() unexpected error: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, journalFile))
+	if err != nil {
+		t.Fatalf("stat journal: %v", err)
+	}
+	if info.Size() != 0 {
+		t.Errorf("journal size after snapshot = %d, want 0", info.Size())
+	}
+
+	r.Create(ctx, modellink.Link{URL: "https://c.com", Alias: "c"})
+	crash(r)
+
+	restored := openPersistent(t, dir)
+	defer restored.Close()
+
+	for _, alias := range []string{"a", "b", "c"} {
+		if _, err := restored.Get(ctx, alias); err != nil {
+			t.Errorf("Get(%s) unexpected error: %v", alias, err)
+		}
+	}
+
+	// eviction order survives the restart: "a" is still the oldest
+	if got := restored.order.Back().Value.(string); got != "a" {
+		t.Errorf("oldest alias after restore = %q, want %q", got, "a")
+	}
+}
+
+func TestPersistence_SnapshotKeepsConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	r := openPersistent(t, dir)
+	r.Create(ctx, modellink.Link{URL: "https://a.com", Alias: "a"})
+
+	// the steps of Snapshot, with writes landing while the store is unlocked
+	entries, offset, err := r.snapshotEntries()
+	if err != nil {
+		t.Fatalf("snapshotEntries() unexpected error: %v", err)
+	}
+
+	r.Create(ctx, modellink.Link{URL: "https://b.com", Alias: "b"})
+	r.Delete(ctx, "a")
+
+	if err := writeSnapshot(dir, entries); err != nil {
+		t.Fatalf("writeSnapshot() unexpected error: %v", err)
+	}
+
+	r.mu.Lock()
+	err = r.journal.compact(offset)
+	r.mu.Unlock()
+	if err != nil {
+		t.Fatalf("compact() unexpected error: %v", err)
+	}
+
+	r.Create(ctx, modellink.Link{URL: "https://c.com", Alias: "c"})
+	crash(r)
+
+	restored := openPersistent(t, dir)
+	defer restored.Close()
+
+	if _, err := restored.Get(ctx, "a"); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("Get(a) error = %v, want %v", err, models.ErrNotFound)
+	}
+	for _, alias := range []string{"b", "c"} {
+		if _, err := restored.Get(ctx, alias); err != nil {
+			t.Errorf("Get(%s) unexpected error: %v", alias, err)
+		}
+	}
+}
+
+func TestPersistence_DiscardsCorruptedTail(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(t *testing.T, path string)
+	}{
+		{
+			name: "truncated",
+			corrupt: func(t *testing.T, path string) {
+				info, _ := os.Stat(path)
+				if err := os.Truncate(path, info.Size()-3); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "checksum",
+			corrupt: func(t *testing.T, path string) {
+				data, _ := os.ReadFile(path)
+				data[len(data)-2] ^= 0xff
+				if err := os.WriteFile(path, data, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			dir := t.TempDir()
+
+			r := openPersistent(t, dir)
+			r.Create(ctx, modellink.Link{URL: "https://a.com", Alias: "a"})
+			r.Create(ctx, modellink.Link{URL: "https://b.com", Alias: "b"})
+			crash(r)
+
+			tt.corrupt(t, filepath.Join(dir, journalFile))
+
+			restored := openPersistent(t, dir)
+
+			if _, err := restored.Get(ctx, "a"); err != nil {
+				t.Errorf("Get(a) unexpected error: %v", err)
+			}
+			if _, err := restored.Get(ctx, "b"); !errors.Is(err, models.ErrNotFound) {
+				t.Errorf("Get(b) error = %v, want %v", err, models.ErrNotFound)
+			}
+
+			// new records go after the last intact one
+			restored.Create(ctx, modellink.Link{URL: "https://c.com", Alias: "c"})
+			crash(restored)
+
+			again := openPersistent(t, dir)
+			defer again.Close()
+
+			for _, alias := range []string{"a", "c"} {
+				if _, err := again.Get(ctx, alias); err != nil {
+					t.Errorf("Get(%s) after second restart unexpected error: %v", alias, err)
+				}
+			}
+		})
+	}
+}
+
+func TestPersistence_CloseWritesSnapshot(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	r := openPersistent(t, dir)
+	r.Create(ctx, modellink.Link{URL: "https://a.com", Alias: "a"})
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, snapshotFile)); err != nil {
+		t.Fatalf("snapshot missing after Close: %v", err)
+	}
+
+	restored := openPersistent(t, dir)
+	defer restored.Close()
+
+	if _, err := restored.Get(ctx, "a"); err != nil {
+		t.Errorf("Get(a) unexpected error: %v", err)
+	}
+}
+
+func TestPersistence_LocksDir(t *testing.T) {
+	dir := t.TempDir()
+
+	r := openPersistent(t, dir)
+
+	if _, err := NewPersistent(10, EvictionOldest, dir, false, testLogger()); !errors.Is(err, ErrDirLocked) {
+		t.Fatalf("second NewPersistent() error = %v, want %v", err, ErrDirLocked)
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() unexpected error: %v", err)
+	}
+
+	again := openPersistent(t, dir)
+	defer again.Close()
+}
+
+func TestPersistence_PingFailsAfterClose(t *testing.T) {
+	ctx := context.Background()
+
+	r := openPersistent(t, t.TempDir())
+	if err := r.Ping(ctx); err != nil {
+		t.Fatalf("Ping() unexpected error: %v", err)
+	}
+
+	r.Close()
+
+	if err := r.Ping(ctx); err == nil {
+		t.Error("Ping() after Close expected error, got nil")
+	}
+}
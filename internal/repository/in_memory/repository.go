@@ -1,57 +1,427 @@
 package inmemory
 
 import (
+	"container/list"
 	"context"
+	"fmt"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
 	"github.com/broadcast80/ozon-task/internal/pkg/models"
 )
 
 type repository struct {
-	aliasToURL map[string]string
-	urlToAlias map[string]string
-	mu         sync.RWMutex
+	aliasToURL map[string]modellink.Link
+	// urlToAlias maps a URL to the canonical alias of each owner
+	urlToAlias map[string]map[string]string
+	// revisions holds the previous URLs of updated aliases, oldest first
+	revisions map[string][]models.Revision
+	// created holds every link sorted by (CreatedAt, Alias) for List
+	created []modellink.Cursor
+	mu      sync.RWMutex
+
+	// order holds aliases, the front is the next one to survive eviction
+	order     *list.List
+	elements  map[string]*list.Element
+	size      int
+	policy    EvictionPolicy
+	evictions atomic.Uint64
+
+	// journal is nil unless the store was opened with NewPersistent
+	journal *journal
+
+	hits    map[string]*aliasHits
+	statsMu sync.RWMutex
 }
 
-func New(storeSize int) *repository {
+// New returns a store holding at most storeSize links, a non-positive size
+// means unbounded.
+func New(storeSize int, policy EvictionPolicy) *repository {
+	capacity := max(storeSize, 0)
+
 	return &repository{
-		aliasToURL: make(map[string]string, storeSize),
-		urlToAlias: make(map[string]string, storeSize),
+		aliasToURL: make(map[string]modellink.Link, capacity),
+		urlToAlias: make(map[string]map[string]string, capacity),
+		revisions:  make(map[string][]models.Revision),
 		mu:         sync.RWMutex{},
+		order:      list.New(),
+		elements:   make(map[string]*list.Element, capacity),
+		size:       storeSize,
+		policy:     policy,
+		hits:       make(map[string]*aliasHits),
+		statsMu:    sync.RWMutex{},
 	}
 }
 
-func (r *repository) Create(ctx context.Context, url string, alias string) error {
+func (r *repository) Create(ctx context.Context, link modellink.Link) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.create(link)
+}
+
+// CreateBatch stores links under a single lock acquisition, so no other
+// write interleaves with the batch.
+func (r *repository) CreateBatch(ctx context.Context, links []modellink.Link) ([]error, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, ok := r.aliasToURL[alias]; ok {
+	errs := make([]error, len(links))
+	for i, link := range links {
+		errs[i] = r.create(link)
+	}
+
+	return errs, nil
+}
+
+// Replace swaps every taken alias for its new link in one step, a failure
+// keeps the stored link. When an alias repeats, its last link wins.
+func (r *repository) Replace(ctx context.Context, links []modellink.Link) ([]error, error) {
+	last := make(map[string]int, len(links))
+	for i, link := range links {
+		last[link.Alias] = i
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	errs := make([]error, len(links))
+	for i, link := range links {
+		if last[link.Alias] != i {
+			errs[i] = models.ErrDuplicate
+			continue
+		}
+
+		errs[i] = r.replace(link)
+	}
+
+	return errs, nil
+}
+
+// replace stores link in place of the link under its alias with a single
+// journal record, so neither a failed write nor a crash leaves the alias
+// deleted. The store keeps its size, nothing is evicted. The caller must
+// hold r.mu.
+func (r *repository) replace(link modellink.Link) error {
+	if _, ok := r.aliasToURL[link.Alias]; !ok {
+		return r.create(link)
+	}
+
+	if r.journal != nil {
+		if err := r.journal.append(record{Op: opReplace, Link: link}); err != nil {
+			return err
+		}
+	}
+
+	r.remove(link.Alias)
+	r.put(link)
+
+	return nil
+}
+
+// create stores a new link, evicting another one if the store is full.
+// The caller must hold r.mu.
+func (r *repository) create(link modellink.Link) error {
+	if _, ok := r.aliasToURL[link.Alias]; ok {
 		return models.ErrDuplicate
 	}
 
-	r.aliasToURL[alias] = url
-	r.urlToAlias[url] = alias
+	if r.full() {
+		if r.policy == EvictionReject {
+			return models.ErrStorageFull
+		}
+		if err := r.evict(); err != nil {
+			return err
+		}
+	}
+
+	if r.journal != nil {
+		if err := r.journal.append(record{Op: opCreate, Link: link}); err != nil {
+			return err
+		}
+	}
+
+	r.put(link)
 
 	return nil
+}
 
+func (r *repository) Get(ctx context.Context, alias string) (*modellink.Link, error) {
+	if r.policy == EvictionLRU {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+	} else {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+	}
+
+	link, ok := r.aliasToURL[alias]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+
+	if r.policy == EvictionLRU {
+		r.order.MoveToFront(r.elements[alias])
+	}
+
+	return &link, nil
+}
+
+func (r *repository) URLExists(ctx context.Context, url string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.urlToAlias[url]
+	return ok, nil
 }
 
-func (r *repository) Get(ctx context.Context, alias string) (string, error) {
+func (r *repository) GetAliasByURL(ctx context.Context, owner, url string) (string, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	url, ok := r.aliasToURL[alias]
+	alias, ok := r.urlToAlias[url][owner]
 	if !ok {
 		return "", models.ErrNotFound
 	}
 
-	return url, nil
+	link := r.aliasToURL[alias]
+	if link.Expired(time.Now()) {
+		return "", models.ErrNotFound
+	}
+
+	return alias, nil
 }
 
-func (r *repository) URLExists(ctx context.Context, url string) (bool, error) {
+func (r *repository) GetLinksByURL(ctx context.Context, owner string, urls []string) (map[string]modellink.Link, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	_, ok := r.urlToAlias[url]
-	return ok, nil
+	now := time.Now()
+	links := make(map[string]modellink.Link)
+	for _, url := range urls {
+		alias, ok := r.urlToAlias[url][owner]
+		if !ok {
+			continue
+		}
+		if link := r.aliasToURL[alias]; !link.Expired(now) {
+			links[url] = link
+		}
+	}
+
+	return links, nil
+}
+
+func (r *repository) Delete(ctx context.Context, alias string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.aliasToURL[alias]; !ok {
+		return models.ErrNotFound
+	}
+
+	return r.delete(alias)
+}
+
+func (r *repository) SetDisabled(ctx context.Context, alias string, disabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.aliasToURL[alias]; !ok {
+		return models.ErrNotFound
+	}
+
+	if r.journal != nil {
+		rec := record{Op: opSetDisabled, Link: modellink.Link{Alias: alias, Disabled: disabled}}
+		if err := r.journal.append(rec); err != nil {
+			return err
+		}
+	}
+
+	r.setDisabled(alias, disabled)
+
+	return nil
+}
+
+func (r *repository) Update(ctx context.Context, alias, url string, at time.Time) (*modellink.Link, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	link, ok := r.aliasToURL[alias]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+
+	if link.URL == url {
+		return &link, nil
+	}
+
+	if r.journal != nil {
+		rec := record{
+			Op:       opUpdate,
+			Link:     modellink.Link{Alias: alias, URL: url},
+			At:       at,
+			Revision: len(r.revisions[alias]) + 1,
+		}
+		if err := r.journal.append(rec); err != nil {
+			return nil, err
+		}
+	}
+
+	r.update(alias, url, at)
+
+	link = r.aliasToURL[alias]
+	return &link, nil
+}
+
+func (r *repository) Revisions(ctx context.Context, alias string) ([]models.Revision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.aliasToURL[alias]; !ok {
+		return nil, models.ErrNotFound
+	}
+
+	return slices.Clone(r.revisions[alias]), nil
+}
+
+func (r *repository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var removed int64
+	for alias, link := range r.aliasToURL {
+		if link.Expired(now) {
+			if err := r.delete(alias); err != nil {
+				return removed, err
+			}
+			removed++
+		}
+	}
+
+	return removed, nil
+}
+
+// delete journals the removal of alias and drops it from all indexes.
+// The caller must hold r.mu.
+func (r *repository) delete(alias string) error {
+	if r.journal != nil {
+		if err := r.journal.append(record{Op: opDelete, Link: modellink.Link{Alias: alias}}); err != nil {
+			return err
+		}
+	}
+
+	r.remove(alias)
+
+	return nil
+}
+
+// put adds link to all indexes. The caller must hold r.mu.
+func (r *repository) put(link modellink.Link) {
+	r.aliasToURL[link.Alias] = link
+	r.elements[link.Alias] = r.order.PushFront(link.Alias)
+	r.addCreated(link)
+	if !link.Disabled {
+		r.index(link)
+	}
+}
+
+// update points an existing alias to url, moves it in the URL index and
+// records the previous URL. The caller must hold r.mu.
+func (r *repository) update(alias, url string, at time.Time) {
+	link := r.aliasToURL[alias]
+
+	r.unindex(link)
+	r.revisions[alias] = append(r.revisions[alias], models.Revision{URL: link.URL, ReplacedAt: at})
+
+	link.URL = url
+	r.aliasToURL[alias] = link
+
+	if !link.Disabled {
+		r.index(link)
+	}
+}
+
+// index makes link the canonical alias of its URL for its owner unless
+// there already is a live one. The caller must hold r.mu.
+func (r *repository) index(link modellink.Link) {
+	// a URL may have several custom aliases, the first one stays canonical
+	// until it expires, then the alias created to replace it takes over
+	owners, ok := r.urlToAlias[link.URL]
+	if !ok {
+		owners = make(map[string]string, 1)
+		r.urlToAlias[link.URL] = owners
+	}
+	if alias, ok := owners[link.Owner]; ok {
+		if canonical := r.aliasToURL[alias]; !canonical.Expired(time.Now()) {
+			return
+		}
+	}
+	owners[link.Owner] = link.Alias
+}
+
+// unindex drops link from the URL index if it is canonical there. The
+// caller must hold r.mu.
+func (r *repository) unindex(link modellink.Link) {
+	owners := r.urlToAlias[link.URL]
+	if owners[link.Owner] == link.Alias {
+		delete(owners, link.Owner)
+		if len(owners) == 0 {
+			delete(r.urlToAlias, link.URL)
+		}
+	}
+}
+
+// setDisabled updates the flag of an existing alias. Disabled links leave
+// the URL index, so shortening their URL again yields a working alias. The
+// caller must hold r.mu.
+func (r *repository) setDisabled(alias string, disabled bool) {
+	link := r.aliasToURL[alias]
+	if link.Disabled == disabled {
+		return
+	}
+
+	link.Disabled = disabled
+	r.aliasToURL[alias] = link
+
+	if disabled {
+		r.unindex(link)
+	} else {
+		r.index(link)
+	}
+}
+
+// remove drops alias from all indexes together with its stats, so a link
+// created later under the same alias starts without them. The caller must
+// hold r.mu.
+func (r *repository) remove(alias string) {
+	link := r.aliasToURL[alias]
+
+	r.order.Remove(r.elements[alias])
+	delete(r.elements, alias)
+	delete(r.aliasToURL, alias)
+	delete(r.revisions, alias)
+
+	r.removeCreated(link)
+	r.unindex(link)
+
+	r.statsMu.Lock()
+	delete(r.hits, alias)
+	r.statsMu.Unlock()
+}
+
+// Ping reports whether the store can serve requests.
+func (r *repository) Ping(ctx context.Context) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.journal != nil {
+		if _, err := r.journal.file.Stat(); err != nil {
+			return fmt.Errorf("journal unavailable: %w", err)
+		}
+	}
+
+	return nil
 }
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
 	"github.com/broadcast80/ozon-task/internal/pkg/models"
 )
 
@@ -27,7 +29,7 @@ func TestRepository_Create(t *testing.T) {
 		{
 			name: "duplicate_alias",
 			setup: func(r *repository) {
-				r.Create(context.Background(), "https://example.com", "abc123")
+				r.Create(context.Background(), modellink.Link{URL: "https://example.com", Alias: "abc123"})
 			},
 			url:     "https://new.com",
 			alias:   "abc123",
@@ -37,24 +39,24 @@ func TestRepository_Create(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			r := New(10)
+			r := New(10, EvictionReject)
 			if tt.setup != nil {
 				tt.setup(r)
 			}
 
-			err := r.Create(context.Background(), tt.url, tt.alias)
+			err := r.Create(context.Background(), modellink.Link{URL: tt.url, Alias: tt.alias})
 			if !errors.Is(err, tt.wantErr) {
 				t.Errorf("Create() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 
 			if tt.wantErr == nil {
-				gotURL, err := r.Get(context.Background(), tt.alias)
+				got, err := r.Get(context.Background(), tt.alias)
 				if err != nil {
-					t.Errorf("Get after Create error = %v", err)
+					t.Fatalf("Get after Create error = %v", err)
 				}
-				if gotURL != tt.url {
-					t.Errorf("stored URL = %q, want %q", gotURL, tt.url)
+				if got.URL != tt.url {
+					t.Errorf("stored URL = %q, want %q", got.URL, tt.url)
 				}
 
 				exists, _ := r.URLExists(context.Background(), tt.url)
@@ -67,10 +69,10 @@ func TestRepository_Create(t *testing.T) {
 }
 
 func TestRepository_Get(t *testing.T) {
-	r := New(10)
+	r := New(10, EvictionReject)
 
-	r.Create(context.Background(), "https://hooli.com", "abc123")
-	r.Create(context.Background(), "https://google.com", "google")
+	r.Create(context.Background(), modellink.Link{URL: "https://hooli.com", Alias: "abc123"})
+	r.Create(context.Background(), modellink.Link{URL: "https://google.com", Alias: "google"})
 
 	tests := []struct {
 		name    string
@@ -99,22 +101,22 @@ func TestRepository_Get(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotURL, err := r.Get(context.Background(), tt.alias)
+			got, err := r.Get(context.Background(), tt.alias)
 			if !errors.Is(err, tt.wantErr) {
 				t.Errorf("Get(%q) error = %v, wantErr %v", tt.alias, err, tt.wantErr)
 				return
 			}
-			if gotURL != tt.wantURL {
-				t.Errorf("Get(%q) = %q, want %q", tt.alias, gotURL, tt.wantURL)
+			if tt.wantErr == nil && got.URL != tt.wantURL {
+				t.Errorf("Get(%q) = %q, want %q", tt.alias, got.URL, tt.wantURL)
 			}
 		})
 	}
 }
 
 func TestRepository_URLExists(t *testing.T) {
-	r := New(10)
+	r := New(10, EvictionReject)
 
-	r.Create(context.Background(), "https://dogville.com", "abc123")
+	r.Create(context.Background(), modellink.Link{URL: "https://dogville.com", Alias: "abc123"})
 
 	tests := []struct {
 		name string
@@ -145,3 +147,361 @@ func TestRepository_URLExists(t *testing.T) {
 		})
 	}
 }
+
+func TestRepository_GetAliasByURL(t *testing.T) {
+	r := New(10, EvictionReject)
+
+	r.Create(context.Background(), modellink.Link{URL: "https://dogville.com", Alias: "abc123"})
+
+	alias, err := r.GetAliasByURL(context.Background(), "", "https://dogville.com")
+	if err != nil {
+		t.Fatalf("GetAliasByURL() unexpected error: %v", err)
+	}
+	if alias != "abc123" {
+		t.Errorf("GetAliasByURL() = %q, want %q", alias, "abc123")
+	}
+
+	if _, err := r.GetAliasByURL(context.Background(), "", "https://nonexistent.com"); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("GetAliasByURL() error = %v, want %v", err, models.ErrNotFound)
+	}
+}
+
+func TestRepository_SeveralAliasesForURL(t *testing.T) {
+	r := New(10, EvictionReject)
+
+	r.Create(context.Background(), modellink.Link{URL: "https://dogville.com", Alias: "first"})
+	r.Create(context.Background(), modellink.Link{URL: "https://dogville.com", Alias: "second"})
+
+	alias, _ := r.GetAliasByURL(context.Background(), "", "https://dogville.com")
+	if alias != "first" {
+		t.Errorf("GetAliasByURL() = %q, want %q", alias, "first")
+	}
+
+	r.Delete(context.Background(), "second")
+
+	alias, _ = r.GetAliasByURL(context.Background(), "", "https://dogville.com")
+	if alias != "first" {
+		t.Errorf("GetAliasByURL() after Delete = %q, want %q", alias, "first")
+	}
+}
+
+func TestRepository_GetAliasByURL_ExpiredCanonical(t *testing.T) {
+	ctx := context.Background()
+	r := New(10, EvictionReject)
+
+	past := time.Now().Add(-time.Minute)
+	r.Create(ctx, modellink.Link{URL: "https://dogville.com", Alias: "old", ExpiresAt: &past})
+
+	if _, err := r.GetAliasByURL(ctx, "", "https://dogville.com"); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("GetAliasByURL() error = %v, want %v", err, models.ErrNotFound)
+	}
+
+	// the alias created to replace the expired one becomes canonical
+	r.Create(ctx, modellink.Link{URL: "https://dogville.com", Alias: "new"})
+	r.Create(ctx, modellink.Link{URL: "https://dogville.com", Alias: "custom"})
+
+	if alias, _ := r.GetAliasByURL(ctx, "", "https://dogville.com"); alias != "new" {
+		t.Errorf("GetAliasByURL() = %q, want %q", alias, "new")
+	}
+
+	r.Delete(ctx, "old")
+
+	if alias, _ := r.GetAliasByURL(ctx, "", "https://dogville.com"); alias != "new" {
+		t.Errorf("GetAliasByURL() after deleting the expired alias = %q, want %q", alias, "new")
+	}
+}
+
+func TestRepository_GetAliasByURL_PerOwner(t *testing.T) {
+	ctx := context.Background()
+	r := New(10, EvictionReject)
+
+	r.Create(ctx, modellink.Link{URL: "https://dogville.com", Alias: "grace", Owner: "grace"})
+	r.Create(ctx, modellink.Link{URL: "https://dogville.com", Alias: "tom", Owner: "tom"})
+
+	for owner, want := range map[string]string{"grace": "grace", "tom": "tom"} {
+		if alias, _ := r.GetAliasByURL(ctx, owner, "https://dogville.com"); alias != want {
+			t.Errorf("GetAliasByURL(%s) = %q, want %q", owner, alias, want)
+		}
+	}
+
+	if _, err := r.GetAliasByURL(ctx, "", "https://dogville.com"); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("GetAliasByURL() for anonymous error = %v, want %v", err, models.ErrNotFound)
+	}
+
+	r.Delete(ctx, "grace")
+
+	if exists, _ := r.URLExists(ctx, "https://dogville.com"); !exists {
+		t.Error("URLExists() = false while tom still has a link")
+	}
+
+	r.Delete(ctx, "tom")
+
+	if exists, _ := r.URLExists(ctx, "https://dogville.com"); exists {
+		t.Error("URLExists() = true after every link was deleted")
+	}
+}
+
+func TestRepository_Delete(t *testing.T) {
+	r := New(10, EvictionReject)
+
+	r.Create(context.Background(), modellink.Link{URL: "https://dogville.com", Alias: "abc123"})
+
+	if err := r.Delete(context.Background(), "abc123"); err != nil {
+		t.Fatalf("Delete() unexpected error: %v", err)
+	}
+
+	if _, err := r.Get(context.Background(), "abc123"); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("Get after Delete error = %v, want %v", err, models.ErrNotFound)
+	}
+
+	if exists, _ := r.URLExists(context.Background(), "https://dogville.com"); exists {
+		t.Error("URLExists after Delete returned true")
+	}
+
+	if err := r.Delete(context.Background(), "abc123"); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("second Delete() error = %v, want %v", err, models.ErrNotFound)
+	}
+}
+
+func TestRepository_SetDisabled(t *testing.T) {
+	ctx := context.Background()
+	r := New(10, EvictionReject)
+
+	r.Create(ctx, modellink.Link{URL: "https://dogville.com", Alias: "abc123"})
+
+	if err := r.SetDisabled(ctx, "abc123", true); err != nil {
+		t.Fatalf("SetDisabled() unexpected error: %v", err)
+	}
+
+	link, err := r.Get(ctx, "abc123")
+	if err != nil || !link.Disabled {
+		t.Fatalf("Get() = %+v, %v, want disabled link", link, err)
+	}
+
+	if err := r.Create(ctx, modellink.Link{URL: "https://other.com", Alias: "abc123"}); !errors.Is(err, models.ErrDuplicate) {
+		t.Errorf("Create() over disabled alias error = %v, want %v", err, models.ErrDuplicate)
+	}
+
+	if _, err := r.GetAliasByURL(ctx, "", "https://dogville.com"); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("GetAliasByURL() of disabled link error = %v, want %v", err, models.ErrNotFound)
+	}
+
+	if err := r.SetDisabled(ctx, "abc123", false); err != nil {
+		t.Fatalf("SetDisabled(false) unexpected error: %v", err)
+	}
+	if alias, err := r.GetAliasByURL(ctx, "", "https://dogville.com"); err != nil || alias != "abc123" {
+		t.Errorf("GetAliasByURL() after enable = %q, %v, want abc123", alias, err)
+	}
+
+	if err := r.SetDisabled(ctx, "missing", true); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("SetDisabled(missing) error = %v, want %v", err, models.ErrNotFound)
+	}
+}
+
+func TestRepository_SetDisabled_NewAliasBecomesCanonical(t *testing.T) {
+	ctx := context.Background()
+	r := New(10, EvictionReject)
+
+	r.Create(ctx, modellink.Link{URL: "https://dogville.com", Alias: "old"})
+	r.SetDisabled(ctx, "old", true)
+	r.Create(ctx, modellink.Link{URL: "https://dogville.com", Alias: "new"})
+
+	// re-enabling must not take the URL back from the replacement
+	r.SetDisabled(ctx, "old", false)
+
+	if alias, err := r.GetAliasByURL(ctx, "", "https://dogville.com"); err != nil || alias != "new" {
+		t.Errorf("GetAliasByURL() = %q, %v, want new", alias, err)
+	}
+}
+
+func TestRepository_Update(t *testing.T) {
+	ctx := context.Background()
+	r := New(10, EvictionReject)
+
+	first := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+
+	r.Create(ctx, modellink.Link{URL: "https://dogville.com", Alias: "abc123", Owner: "grace"})
+
+	link, err := r.Update(ctx, "abc123", "https://manderlay.com", first)
+	if err != nil {
+		t.Fatalf("Update() unexpected error: %v", err)
+	}
+	if link.URL != "https://manderlay.com" || link.Owner != "grace" {
+		t.Errorf("Update() = %+v, want the new URL and the same owner", link)
+	}
+
+	if _, err := r.GetAliasByURL(ctx, "grace", "https://dogville.com"); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("GetAliasByURL(old URL) error = %v, want %v", err, models.ErrNotFound)
+	}
+	if alias, err := r.GetAliasByURL(ctx, "grace", "https://manderlay.com"); err != nil || alias != "abc123" {
+		t.Errorf("GetAliasByURL(new URL) = %q, %v, want abc123", alias, err)
+	}
+
+	// same URL again is not a revision
+	r.Update(ctx, "abc123", "https://manderlay.com", second)
+	r.Update(ctx, "abc123", "https://dogville.com", second)
+
+	revisions, err := r.Revisions(ctx, "abc123")
+	if err != nil {
+		t.Fatalf("Revisions() unexpected error: %v", err)
+	}
+	want := []models.Revision{
+		{URL: "https://dogville.com", ReplacedAt: first},
+		{URL: "https://manderlay.com", ReplacedAt: second},
+	}
+	if len(revisions) != len(want) || revisions[0] != want[0] || revisions[1] != want[1] {
+		t.Errorf("Revisions() = %+v, want %+v", revisions, want)
+	}
+
+	if _, err := r.Update(ctx, "missing", "https://dogville.com", first); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("Update(missing) error = %v, want %v", err, models.ErrNotFound)
+	}
+	if _, err := r.Revisions(ctx, "missing"); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("Revisions(missing) error = %v, want %v", err, models.ErrNotFound)
+	}
+
+	r.Delete(ctx, "abc123")
+	r.Create(ctx, modellink.Link{URL: "https://dogville.com", Alias: "abc123"})
+
+	if revisions, _ := r.Revisions(ctx, "abc123"); len(revisions) != 0 {
+		t.Errorf("Revisions() of a recreated alias = %+v, want none", revisions)
+	}
+}
+
+func TestRepository_DeleteExpired(t *testing.T) {
+	r := New(10, EvictionReject)
+
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	r.Create(context.Background(), modellink.Link{URL: "https://old.com", Alias: "old", ExpiresAt: &past})
+	r.Create(context.Background(), modellink.Link{URL: "https://fresh.com", Alias: "fresh", ExpiresAt: &future})
+	r.Create(context.Background(), modellink.Link{URL: "https://forever.com", Alias: "forever"})
+
+	if _, err := r.GetAliasByURL(context.Background(), "", "https://old.com"); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("GetAliasByURL() for expired link error = %v, want %v", err, models.ErrNotFound)
+	}
+
+	removed, err := r.DeleteExpired(context.Background(), now)
+	if err != nil {
+		t.Fatalf("DeleteExpired() unexpected error: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("DeleteExpired() = %d, want 1", removed)
+	}
+
+	if _, err := r.Get(context.Background(), "old"); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("Get(old) error = %v, want %v", err, models.ErrNotFound)
+	}
+	if exists, _ := r.URLExists(context.Background(), "https://old.com"); exists {
+		t.Error("URLExists(old) after DeleteExpired returned true")
+	}
+
+	for _, alias := range []string{"fresh", "forever"} {
+		if _, err := r.Get(context.Background(), alias); err != nil {
+			t.Errorf("Get(%s) unexpected error: %v", alias, err)
+		}
+	}
+}
+
+func TestRepository_CreateBatch(t *testing.T) {
+	r := New(3, EvictionReject)
+	ctx := context.Background()
+
+	if err := r.Create(ctx, modellink.Link{URL: "https://example.com", Alias: "taken", Owner: "grace"}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	errs, err := r.CreateBatch(ctx, []modellink.Link{
+		{URL: "https://a.com", Alias: "a"},
+		{URL: "https://b.com", Alias: "taken"},
+		{URL: "https://c.com", Alias: "c"},
+		{URL: "https://d.com", Alias: "d"},
+	})
+	if err != nil {
+		t.Fatalf("CreateBatch() error = %v", err)
+	}
+
+	want := []error{nil, models.ErrDuplicate, nil, models.ErrStorageFull}
+	for i := range want {
+		if !errors.Is(errs[i], want[i]) {
+			t.Errorf("item %d: error = %v, want %v", i, errs[i], want[i])
+		}
+	}
+
+	if _, err := r.Get(ctx, "c"); err != nil {
+		t.Errorf("Get() after CreateBatch error = %v", err)
+	}
+}
+
+func TestRepository_GetLinksByURL(t *testing.T) {
+	r := New(10, EvictionReject)
+	ctx := context.Background()
+
+	past := time.Now().Add(-time.Minute)
+	r.Create(ctx, modellink.Link{URL: "https://a.com", Alias: "a", Owner: "grace"})
+	r.Create(ctx, modellink.Link{URL: "https://b.com", Alias: "b", Owner: "tom"})
+	r.Create(ctx, modellink.Link{URL: "https://old.com", Alias: "old", Owner: "grace", ExpiresAt: &past})
+
+	links, err := r.GetLinksByURL(ctx, "grace", []string{"https://a.com", "https://b.com", "https://old.com", "https://none.com"})
+	if err != nil {
+		t.Fatalf("GetLinksByURL() error = %v", err)
+	}
+
+	if len(links) != 1 || links["https://a.com"].Alias != "a" {
+		t.Errorf("GetLinksByURL() = %v, want only the owner's live link", links)
+	}
+}
+
+func TestRepository_Replace(t *testing.T) {
+	ctx := context.Background()
+	r := New(10, EvictionReject)
+
+	r.Create(ctx, modellink.Link{URL: "https://dogville.com", Alias: "abc123", Owner: "grace"})
+	r.Update(ctx, "abc123", "https://manderlay.com", time.Now())
+
+	errs, err := r.Replace(ctx, []modellink.Link{
+		{URL: "https://dogville.com", Alias: "abc123", Owner: "tom", Disabled: true},
+		{URL: "https://example.com", Alias: "new"},
+	})
+	if err != nil || errs[0] != nil || errs[1] != nil {
+		t.Fatalf("Replace() = %v, %v", errs, err)
+	}
+
+	link, err := r.Get(ctx, "abc123")
+	if err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+	if link.URL != "https://dogville.com" || link.Owner != "tom" || !link.Disabled {
+		t.Errorf("Get() = %+v, want the replacing link", link)
+	}
+
+	if revisions, _ := r.Revisions(ctx, "abc123"); len(revisions) != 0 {
+		t.Errorf("Revisions() = %+v, want the history dropped", revisions)
+	}
+	if _, err := r.GetAliasByURL(ctx, "grace", "https://manderlay.com"); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("GetAliasByURL(replaced URL) error = %v, want %v", err, models.ErrNotFound)
+	}
+	if _, err := r.Get(ctx, "new"); err != nil {
+		t.Errorf("Get(new) unexpected error: %v", err)
+	}
+}
+
+func TestRepository_Replace_RepeatedAlias(t *testing.T) {
+	ctx := context.Background()
+	r := New(10, EvictionReject)
+
+	errs, err := r.Replace(ctx, []modellink.Link{
+		{URL: "https://dogville.com", Alias: "abc123"},
+		{URL: "https://manderlay.com", Alias: "abc123"},
+	})
+	if err != nil || !errors.Is(errs[0], models.ErrDuplicate) || errs[1] != nil {
+		t.Fatalf("Replace() = %v, %v, want the first link reported as duplicate", errs, err)
+	}
+
+	if link, _ := r.Get(ctx, "abc123"); link == nil || link.URL != "https://manderlay.com" {
+		t.Errorf("Get() = %+v, want the last link", link)
+	}
+}
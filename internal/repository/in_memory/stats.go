@@ -0,0 +1,77 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+const dateLayout = "2006-01-02"
+
+// aliasHits keeps aggregated counters only, raw hits are not retained.
+type aliasHits struct {
+	total int64
+	last  time.Time
+	daily map[string]int64
+}
+
+// RecordHits counts hits of stored links. Hits of aliases deleted or
+// evicted since the redirect are dropped, the stats of a link go with it.
+func (r *repository) RecordHits(ctx context.Context, hits []models.Hit) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+
+	for _, hit := range hits {
+		if _, ok := r.aliasToURL[hit.Alias]; !ok {
+			continue
+		}
+
+		h, ok := r.hits[hit.Alias]
+		if !ok {
+			h = &aliasHits{daily: make(map[string]int64)}
+			r.hits[hit.Alias] = h
+		}
+
+		h.total++
+		if hit.At.After(h.last) {
+			h.last = hit.At
+		}
+		h.daily[hit.At.UTC().Format(dateLayout)]++
+	}
+
+	return nil
+}
+
+func (r *repository) GetStats(ctx context.Context, alias string, since time.Time) (*models.Stats, error) {
+	r.statsMu.RLock()
+	defer r.statsMu.RUnlock()
+
+	stats := &models.Stats{Alias: alias, Daily: []models.DailyCount{}}
+
+	h, ok := r.hits[alias]
+	if !ok {
+		return stats, nil
+	}
+
+	last := h.last
+	stats.Total = h.total
+	stats.LastAccessAt = &last
+
+	from := since.UTC().Format(dateLayout)
+	for date, count := range h.daily {
+		if date >= from {
+			stats.Daily = append(stats.Daily, models.DailyCount{Date: date, Count: count})
+		}
+	}
+
+	sort.Slice(stats.Daily, func(i, j int) bool {
+		return stats.Daily[i].Date < stats.Daily[j].Date
+	})
+
+	return stats, nil
+}
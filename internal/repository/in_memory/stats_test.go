@@ -0,0 +1,98 @@
+package inmemory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+func TestRepository_Stats(t *testing.T) {
+	r := New(10, EvictionReject)
+	r.Create(context.Background(), modellink.Link{URL: "https://dogville.com", Alias: "abc"})
+	r.Create(context.Background(), modellink.Link{URL: "https://manderlay.com", Alias: "other"})
+
+	day1 := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	day2 := time.Date(2026, 10, 3, 8, 0, 0, 0, time.UTC)
+
+	err := r.RecordHits(context.Background(), []models.Hit{
+		{Alias: "abc", At: day1},
+		{Alias: "abc", At: day2},
+		{Alias: "abc", At: day2.Add(time.Hour)},
+		{Alias: "other", At: day2},
+	})
+	if err != nil {
+		t.Fatalf("RecordHits() unexpected error: %v", err)
+	}
+
+	stats, err := r.GetStats(context.Background(), "abc", day1)
+	if err != nil {
+		t.Fatalf("GetStats() unexpected error: %v", err)
+	}
+
+	if stats.Total != 3 {
+		t.Errorf("Total = %d, want 3", stats.Total)
+	}
+	if stats.LastAccessAt == nil || !stats.LastAccessAt.Equal(day2.Add(time.Hour)) {
+		t.Errorf("LastAccessAt = %v, want %v", stats.LastAccessAt, day2.Add(time.Hour))
+	}
+
+	want := []models.DailyCount{{Date: "2026-10-01", Count: 1}, {Date: "2026-10-03", Count: 2}}
+	if len(stats.Daily) != len(want) {
+		t.Fatalf("Daily = %v, want %v", stats.Daily, want)
+	}
+	for i := range want {
+		if stats.Daily[i] != want[i] {
+			t.Errorf("Daily[%d] = %v, want %v", i, stats.Daily[i], want[i])
+		}
+	}
+
+	windowed, _ := r.GetStats(context.Background(), "abc", day2)
+	if len(windowed.Daily) != 1 || windowed.Total != 3 {
+		t.Errorf("windowed stats = %+v, want one day and total 3", windowed)
+	}
+
+	empty, _ := r.GetStats(context.Background(), "missing", day1)
+	if empty.Total != 0 || empty.LastAccessAt != nil || len(empty.Daily) != 0 {
+		t.Errorf("stats for missing alias = %+v, want empty", empty)
+	}
+}
+
+func TestRepository_Stats_DeletedAlias(t *testing.T) {
+	ctx := context.Background()
+	r := New(10, EvictionReject)
+
+	at := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	r.Create(ctx, modellink.Link{URL: "https://dogville.com", Alias: "abc", Owner: "grace"})
+	r.RecordHits(ctx, []models.Hit{{Alias: "abc", At: at}})
+	r.Delete(ctx, "abc")
+
+	// the freed alias starts over for its next owner
+	r.Create(ctx, modellink.Link{URL: "https://manderlay.com", Alias: "abc", Owner: "tom"})
+
+	stats, err := r.GetStats(ctx, "abc", at)
+	if err != nil {
+		t.Fatalf("GetStats() unexpected error: %v", err)
+	}
+	if stats.Total != 0 || stats.LastAccessAt != nil {
+		t.Errorf("stats after re-create = %+v, want empty", stats)
+	}
+}
+
+func TestRepository_Stats_Bounded(t *testing.T) {
+	ctx := context.Background()
+	r := New(1, EvictionOldest)
+
+	at := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	r.Create(ctx, modellink.Link{URL: "https://dogville.com", Alias: "old"})
+	r.RecordHits(ctx, []models.Hit{{Alias: "old", At: at}, {Alias: "unknown", At: at}})
+	r.Create(ctx, modellink.Link{URL: "https://manderlay.com", Alias: "new"})
+
+	if len(r.hits) != 0 {
+		t.Errorf("hits kept for %d aliases, want none", len(r.hits))
+	}
+}
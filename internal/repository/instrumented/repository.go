@@ -0,0 +1,148 @@
+package instrumented
+
+import (
+	"context"
+	"time"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/metrics"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+	"github.com/broadcast80/ozon-task/internal/pkg/tracing"
+	"github.com/broadcast80/ozon-task/internal/usecase"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	tracer = otel.Tracer("github.com/broadcast80/ozon-task/internal/repository/instrumented")
+
+	operationDuration = metrics.Default.NewHistogramVec(
+		"shortener_repository_operation_duration_seconds",
+		"Repository call latency, by storage backend and operation.",
+		metrics.DefBuckets,
+		"backend", "operation",
+	)
+)
+
+// repository measures and traces every call to the wrapped repository. It
+// is placed below the cache so both reflect the storage backend itself.
+type repository struct {
+	next    usecase.RepositoryInterface
+	backend string
+}
+
+func New(next usecase.RepositoryInterface, backend string) *repository {
+	return &repository{
+		next:    next,
+		backend: backend,
+	}
+}
+
+// start opens a client span for operation, the returned function ends it
+// and records the latency.
+func (r *repository) start(ctx context.Context, operation string, attributes ...attribute.KeyValue) (context.Context, func(error)) {
+	begin := time.Now()
+
+	ctx, span := tracer.Start(ctx, "repository."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(tracing.BackendKey.String(r.backend)),
+		trace.WithAttributes(attributes...),
+	)
+
+	return ctx, func(err error) {
+		operationDuration.With(r.backend, operation).ObserveSince(begin)
+		tracing.End(span, err)
+	}
+}
+
+func (r *repository) Create(ctx context.Context, link modellink.Link) error {
+	ctx, finish := r.start(ctx, "create", tracing.AliasKey.String(link.Alias))
+	err := r.next.Create(ctx, link)
+	finish(err)
+	return err
+}
+
+func (r *repository) Get(ctx context.Context, alias string) (*modellink.Link, error) {
+	ctx, finish := r.start(ctx, "get", tracing.AliasKey.String(alias))
+	link, err := r.next.Get(ctx, alias)
+	finish(err)
+	return link, err
+}
+
+func (r *repository) URLExists(ctx context.Context, url string) (bool, error) {
+	ctx, finish := r.start(ctx, "url_exists")
+	exists, err := r.next.URLExists(ctx, url)
+	finish(err)
+	return exists, err
+}
+
+func (r *repository) GetAliasByURL(ctx context.Context, owner, url string) (string, error) {
+	ctx, finish := r.start(ctx, "get_alias_by_url")
+	alias, err := r.next.GetAliasByURL(ctx, owner, url)
+	finish(err)
+	return alias, err
+}
+
+func (r *repository) GetLinksByURL(ctx context.Context, owner string, urls []string) (map[string]modellink.Link, error) {
+	ctx, finish := r.start(ctx, "get_links_by_url")
+	links, err := r.next.GetLinksByURL(ctx, owner, urls)
+	finish(err)
+	return links, err
+}
+
+func (r *repository) CreateBatch(ctx context.Context, links []modellink.Link) ([]error, error) {
+	ctx, finish := r.start(ctx, "create_batch")
+	errs, err := r.next.CreateBatch(ctx, links)
+	finish(err)
+	return errs, err
+}
+
+func (r *repository) Replace(ctx context.Context, links []modellink.Link) ([]error, error) {
+	ctx, finish := r.start(ctx, "replace")
+	errs, err := r.next.Replace(ctx, links)
+	finish(err)
+	return errs, err
+}
+
+func (r *repository) Delete(ctx context.Context, alias string) error {
+	ctx, finish := r.start(ctx, "delete", tracing.AliasKey.String(alias))
+	err := r.next.Delete(ctx, alias)
+	finish(err)
+	return err
+}
+
+func (r *repository) SetDisabled(ctx context.Context, alias string, disabled bool) error {
+	ctx, finish := r.start(ctx, "set_disabled", tracing.AliasKey.String(alias))
+	err := r.next.SetDisabled(ctx, alias, disabled)
+	finish(err)
+	return err
+}
+
+func (r *repository) Update(ctx context.Context, alias, url string, at time.Time) (*modellink.Link, error) {
+	ctx, finish := r.start(ctx, "update", tracing.AliasKey.String(alias))
+	link, err := r.next.Update(ctx, alias, url, at)
+	finish(err)
+	return link, err
+}
+
+func (r *repository) Revisions(ctx context.Context, alias string) ([]models.Revision, error) {
+	ctx, finish := r.start(ctx, "revisions", tracing.AliasKey.String(alias))
+	revisions, err := r.next.Revisions(ctx, alias)
+	finish(err)
+	return revisions, err
+}
+
+func (r *repository) List(ctx context.Context, filter modellink.Filter) (*modellink.Page, error) {
+	ctx, finish := r.start(ctx, "list")
+	page, err := r.next.List(ctx, filter)
+	finish(err)
+	return page, err
+}
+
+func (r *repository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	ctx, finish := r.start(ctx, "delete_expired")
+	removed, err := r.next.DeleteExpired(ctx, now)
+	finish(err)
+	return removed, err
+}
@@ -0,0 +1,80 @@
+package instrumented
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/metrics"
+	"github.com/broadcast80/ozon-task/internal/pkg/tracing"
+	inmemory "github.com/broadcast80/ozon-task/internal/repository/in_memory"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRepository_ObservesOperations(t *testing.T) {
+	ctx := context.Background()
+	r := New(inmemory.New(10, inmemory.EvictionReject), "test")
+
+	if err := r.Create(ctx, modellink.Link{URL: "https://a.com", Alias: "a"}); err != nil {
+		t.Fatalf("Create() unexpected error: %v", err)
+	}
+	if _, err := r.Get(ctx, "a"); err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+	r.Get(ctx, "missing")
+
+	var b strings.Builder
+	if err := metrics.Default.WriteText(&b); err != nil {
+		t.Fatalf("WriteText() unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		`shortener_repository_operation_duration_seconds_count{backend="test",operation="create"} 1`,
+		`shortener_repository_operation_duration_seconds_count{backend="test",operation="get"} 2`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("expected %q in exposition:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestRepository_Spans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	r := New(inmemory.New(10, inmemory.EvictionReject), "inmemory")
+
+	r.Get(ctx, "missing")
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+
+	span := spans[0]
+	if span.Name() != "repository.get" {
+		t.Errorf("expected span name %q, got %q", "repository.get", span.Name())
+	}
+	if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("expected repository span to be a child of the caller span")
+	}
+	if span.Status().Code == codes.Error {
+		t.Error("a missing alias must not mark the span as failed")
+	}
+
+	attributes := attribute.NewSet(span.Attributes()...)
+	if v, _ := attributes.Value(tracing.BackendKey); v.AsString() != "inmemory" {
+		t.Errorf("expected backend attribute, got %q", v.AsString())
+	}
+	if v, _ := attributes.Value(tracing.AliasKey); v.AsString() != "missing" {
+		t.Errorf("expected alias attribute, got %q", v.AsString())
+	}
+}
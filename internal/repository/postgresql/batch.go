@@ -0,0 +1,175 @@
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateBatch inserts links with a single multi-row statement. Rows whose
+// alias is taken are skipped by ON CONFLICT and reported as ErrDuplicate,
+// any other failure rolls the whole batch back.
+func (r *repository) CreateBatch(ctx context.Context, links []modellink.Link) ([]error, error) {
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	inserted, err := insertLinks(ctx, tx, links)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	errs := make([]error, len(links))
+	for i, link := range links {
+		if !inserted[link.Alias] {
+			errs[i] = models.ErrDuplicate
+		}
+	}
+
+	return errs, nil
+}
+
+// Replace deletes the links stored under the aliases of links, which
+// cascades to their revisions, and their hits, then inserts links in the
+// same transaction. When an alias repeats, its last link wins and the
+// earlier ones are reported as ErrDuplicate.
+func (r *repository) Replace(ctx context.Context, links []modellink.Link) ([]error, error) {
+	last := make(map[string]int, len(links))
+	for i, link := range links {
+		last[link.Alias] = i
+	}
+
+	var (
+		aliases = make([]string, 0, len(last))
+		unique  = make([]modellink.Link, 0, len(last))
+	)
+	for i, link := range links {
+		if last[link.Alias] == i {
+			aliases = append(aliases, link.Alias)
+			unique = append(unique, link)
+		}
+	}
+
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM link WHERE alias = ANY($1)`, aliases); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM link_hit WHERE alias = ANY($1)`, aliases); err != nil {
+		return nil, err
+	}
+
+	if _, err := insertLinks(ctx, tx, unique); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	errs := make([]error, len(links))
+	for i, link := range links {
+		if last[link.Alias] != i {
+			errs[i] = models.ErrDuplicate
+		}
+	}
+
+	return errs, nil
+}
+
+// insertLinks inserts links skipping taken aliases and returns the aliases
+// that were inserted.
+func insertLinks(ctx context.Context, tx pgx.Tx, links []modellink.Link) (map[string]bool, error) {
+	var (
+		urls       = make([]string, len(links))
+		aliases    = make([]string, len(links))
+		expiresAt  = make([]*time.Time, len(links))
+		owners     = make([]string, len(links))
+		createdAts = make([]*time.Time, len(links))
+		disabled   = make([]bool, len(links))
+	)
+	for i, link := range links {
+		urls[i] = link.URL
+		aliases[i] = link.Alias
+		expiresAt[i] = link.ExpiresAt
+		owners[i] = link.Owner
+		if !link.CreatedAt.IsZero() {
+			createdAts[i] = &links[i].CreatedAt
+		}
+		disabled[i] = link.Disabled
+	}
+
+	q := `
+		INSERT INTO link (url, alias, expires_at, owner, created_at, disabled)
+		SELECT url, alias, expires_at, owner, COALESCE(created_at, now()), disabled
+		FROM unnest($1::text[], $2::text[], $3::timestamptz[], $4::text[], $5::timestamptz[], $6::bool[])
+			AS new_link (url, alias, expires_at, owner, created_at, disabled)
+		ON CONFLICT (alias) DO NOTHING
+		RETURNING alias
+	`
+
+	rows, err := tx.Query(ctx, q, urls, aliases, expiresAt, owners, createdAts, disabled)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	inserted := make(map[string]bool, len(links))
+	for rows.Next() {
+		var alias string
+		if err := rows.Scan(&alias); err != nil {
+			return nil, err
+		}
+		inserted[alias] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return inserted, nil
+}
+
+func (r *repository) GetLinksByURL(ctx context.Context, owner string, urls []string) (map[string]modellink.Link, error) {
+	q := `
+		SELECT DISTINCT ON (url) url, alias, expires_at, disabled, created_at
+		FROM link
+		WHERE owner = $1
+		  AND url = ANY($2)
+		  AND NOT disabled
+		  AND (expires_at IS NULL OR expires_at > now())
+		ORDER BY url, id
+	`
+
+	rows, err := r.client.Query(ctx, q, owner, urls)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := make(map[string]modellink.Link)
+	for rows.Next() {
+		link := modellink.Link{Owner: owner}
+		if err := rows.Scan(&link.URL, &link.Alias, &link.ExpiresAt, &link.Disabled, &link.CreatedAt); err != nil {
+			return nil, err
+		}
+		links[link.URL] = link
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
@@ -0,0 +1,97 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_CreateBatch(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := New(pool)
+	ctx := context.Background()
+
+	require.NoError(t, repo.Create(ctx, modellink.Link{URL: "https://example.com", Alias: "taken"}))
+
+	future := time.Now().Add(time.Hour)
+	errs, err := repo.CreateBatch(ctx, []modellink.Link{
+		{URL: "https://a.com", Alias: "a", Owner: "grace", ExpiresAt: &future},
+		{URL: "https://b.com", Alias: "taken"},
+		{URL: "https://c.com", Alias: "c"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, errs[0])
+	require.ErrorIs(t, errs[1], models.ErrDuplicate)
+	require.NoError(t, errs[2])
+
+	link, err := repo.Get(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, "grace", link.Owner)
+	require.NotNil(t, link.ExpiresAt)
+	require.False(t, link.CreatedAt.IsZero())
+
+	link, err = repo.Get(ctx, "taken")
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com", link.URL)
+}
+
+func TestRepository_GetLinksByURL(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := New(pool)
+	ctx := context.Background()
+
+	past := time.Now().Add(-time.Minute)
+	require.NoError(t, repo.Create(ctx, modellink.Link{URL: "https://a.com", Alias: "a", Owner: "grace"}))
+	require.NoError(t, repo.Create(ctx, modellink.Link{URL: "https://a.com", Alias: "a2", Owner: "grace"}))
+	require.NoError(t, repo.Create(ctx, modellink.Link{URL: "https://b.com", Alias: "b", Owner: "tom"}))
+	require.NoError(t, repo.Create(ctx, modellink.Link{URL: "https://old.com", Alias: "old", Owner: "grace", ExpiresAt: &past}))
+
+	links, err := repo.GetLinksByURL(ctx, "grace", []string{"https://a.com", "https://b.com", "https://old.com"})
+	require.NoError(t, err)
+	require.Len(t, links, 1)
+	require.Equal(t, "a", links["https://a.com"].Alias)
+}
+
+func TestRepository_Replace(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := New(pool)
+	ctx := context.Background()
+
+	createdAt := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, repo.Create(ctx, modellink.Link{URL: "https://example.com", Alias: "test", Owner: "grace"}))
+	_, err := repo.Update(ctx, "test", "https://example.org", time.Now())
+	require.NoError(t, err)
+
+	errs, err := repo.Replace(ctx, []modellink.Link{
+		{URL: "https://first.com", Alias: "test"},
+		{URL: "https://dogville.com", Alias: "test", Owner: "tom", Disabled: true, CreatedAt: createdAt},
+		{URL: "https://new.com", Alias: "new"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []error{models.ErrDuplicate, nil, nil}, errs)
+
+	link, err := repo.Get(ctx, "test")
+	require.NoError(t, err)
+	require.Equal(t, "https://dogville.com", link.URL)
+	require.Equal(t, "tom", link.Owner)
+	require.True(t, link.Disabled)
+	require.True(t, link.CreatedAt.Equal(createdAt))
+
+	revisions, err := repo.Revisions(ctx, "test")
+	require.NoError(t, err)
+	require.Empty(t, revisions)
+
+	_, err = repo.Get(ctx, "new")
+	require.NoError(t, err)
+}
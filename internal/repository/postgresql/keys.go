@@ -0,0 +1,31 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// LookupKey returns the owner of an active API key by its hash.
+func (r *repository) LookupKey(ctx context.Context, hash string) (*models.Principal, error) {
+	q := `
+		SELECT owner, admin
+		FROM api_key
+		WHERE key_hash = $1
+		  AND revoked_at IS NULL
+	`
+
+	var principal models.Principal
+
+	err := r.client.QueryRow(ctx, q, hash).Scan(&principal.Owner, &principal.Admin)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &principal, nil
+}
@@ -0,0 +1,57 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_LookupKey(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := New(pool)
+	ctx := context.Background()
+
+	_, err := pool.Exec(ctx, `
+		INSERT INTO api_key (key_hash, owner, admin, revoked_at)
+		VALUES ('active', 'grace', false, NULL), ('root', 'ops', true, NULL), ('old', 'tom', false, now())
+	`)
+	require.NoError(t, err)
+
+	principal, err := repo.LookupKey(ctx, "active")
+	require.NoError(t, err)
+	require.Equal(t, models.Principal{Owner: "grace"}, *principal)
+
+	principal, err = repo.LookupKey(ctx, "root")
+	require.NoError(t, err)
+	require.True(t, principal.Admin)
+
+	_, err = repo.LookupKey(ctx, "old")
+	require.ErrorIs(t, err, models.ErrNotFound)
+}
+
+func TestRepository_Owner(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := New(pool)
+	ctx := context.Background()
+
+	require.NoError(t, repo.Create(ctx, modellink.Link{URL: "https://example.com", Alias: "grace", Owner: "grace"}))
+	require.NoError(t, repo.Create(ctx, modellink.Link{URL: "https://example.com", Alias: "tom", Owner: "tom"}))
+
+	link, err := repo.Get(ctx, "grace")
+	require.NoError(t, err)
+	require.Equal(t, "grace", link.Owner)
+
+	alias, err := repo.GetAliasByURL(ctx, "tom", "https://example.com")
+	require.NoError(t, err)
+	require.Equal(t, "tom", alias)
+
+	_, err = repo.GetAliasByURL(ctx, "", "https://example.com")
+	require.ErrorIs(t, err, models.ErrNotFound)
+}
@@ -0,0 +1,107 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+)
+
+// List pages through links with a keyset on (created_at, id), so every page
+// is a single index range scan regardless of how deep the listing goes.
+func (r *repository) List(ctx context.Context, filter modellink.Filter) (*modellink.Page, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	where := func(condition string, values ...any) {
+		placeholders := make([]any, len(values))
+		for i, value := range values {
+			args = append(args, value)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		conditions = append(conditions, fmt.Sprintf(condition, placeholders...))
+	}
+
+	if filter.Owner != "" {
+		where("owner = %s", filter.Owner)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		where("created_at >= %s", filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		where("created_at < %s", filter.CreatedBefore)
+	}
+	if filter.URLContains != "" {
+		where("strpos(url, %s) > 0", filter.URLContains)
+	}
+	if filter.Domain != "" {
+		// URLs are normalized and carry no userinfo, so the host is
+		// everything up to the port or path, and ".host" ending with
+		// ".domain" covers subdomains as well
+		where("right('.' || substring(url from '^[a-z]+://([^/:?#]+)'), length(%[1]s) + 1) = '.' || %[1]s",
+			filter.Domain)
+	}
+
+	order := "DESC"
+	if filter.Ascending {
+		order = "ASC"
+	}
+
+	if filter.After != nil {
+		operator := "<"
+		if filter.Ascending {
+			operator = ">"
+		}
+		where("(created_at, id) "+operator+" (%s::timestamptz, %s::int8)", filter.After.CreatedAt, filter.After.ID)
+	}
+
+	q := `
+		SELECT id, url, alias, expires_at, owner, disabled, created_at
+		FROM link
+	`
+	if len(conditions) > 0 {
+		q += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	q += fmt.Sprintf(" ORDER BY created_at %[1]s, id %[1]s", order)
+	if filter.Limit > 0 {
+		// one extra row tells whether there is a next page
+		q += fmt.Sprintf(" LIMIT %d", filter.Limit+1)
+	}
+
+	rows, err := r.client.Query(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		page modellink.Page
+		ids  []int64
+	)
+	for rows.Next() {
+		var (
+			id   int64
+			link modellink.Link
+		)
+		err := rows.Scan(&id, &link.URL, &link.Alias, &link.ExpiresAt, &link.Owner, &link.Disabled, &link.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		page.Links = append(page.Links, link)
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if filter.Limit > 0 && len(page.Links) > filter.Limit {
+		page.Links = page.Links[:filter.Limit]
+		last := page.Links[filter.Limit-1]
+		page.Next = &modellink.Cursor{CreatedAt: last.CreatedAt, ID: ids[filter.Limit-1]}
+	}
+
+	return &page, nil
+}
@@ -0,0 +1,61 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_List(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := New(pool)
+	ctx := context.Background()
+
+	base := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, link := range []modellink.Link{
+		{URL: "https://dogville.com/", Alias: "a", Owner: "grace"},
+		{URL: "https://shop.dogville.com/sale", Alias: "b", Owner: "grace"},
+		{URL: "https://notdogville.com/", Alias: "c", Owner: "tom"},
+		{URL: "https://manderlay.com/sale", Alias: "d", Owner: "tom"},
+	} {
+		link.CreatedAt = base.Add(time.Duration(i) * time.Hour)
+		require.NoError(t, repo.Create(ctx, link))
+	}
+
+	aliases := func(filter modellink.Filter) []string {
+		page, err := repo.List(ctx, filter)
+		require.NoError(t, err)
+
+		var result []string
+		for _, link := range page.Links {
+			result = append(result, link.Alias)
+		}
+		return result
+	}
+
+	require.Equal(t, []string{"d", "c", "b", "a"}, aliases(modellink.Filter{}))
+	require.Equal(t, []string{"d", "c"}, aliases(modellink.Filter{Owner: "tom"}))
+	require.Equal(t, []string{"d", "c"}, aliases(modellink.Filter{CreatedAfter: base.Add(2 * time.Hour)}))
+	require.Equal(t, []string{"a"}, aliases(modellink.Filter{CreatedBefore: base.Add(time.Hour)}))
+	require.Equal(t, []string{"d", "b"}, aliases(modellink.Filter{URLContains: "/sale"}))
+	require.Equal(t, []string{"b", "a"}, aliases(modellink.Filter{Domain: "dogville.com"}))
+
+	filter := modellink.Filter{Ascending: true, Limit: 3}
+
+	page, err := repo.List(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, page.Links, 3)
+	require.NotNil(t, page.Next)
+
+	filter.After = page.Next
+	page, err = repo.List(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, page.Links, 1)
+	require.Equal(t, "d", page.Links[0].Alias)
+	require.Nil(t, page.Next)
+}
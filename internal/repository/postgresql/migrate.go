@@ -0,0 +1,87 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"slices"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationLock serializes concurrent Migrate calls across processes.
+const migrationLock = 7264001
+
+// Migrate applies the *.sql files of migrations that are not recorded in
+// schema_migration yet, in name order and each in its own transaction, and
+// returns the applied names. The migrations are idempotent, so a database
+// created by the container init scripts is caught up safely.
+func Migrate(ctx context.Context, client *pgxpool.Pool, migrations fs.FS) ([]string, error) {
+	q := `
+		CREATE TABLE IF NOT EXISTS schema_migration (
+			version TEXT PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)
+	`
+	if _, err := client.Exec(ctx, q); err != nil {
+		return nil, err
+	}
+
+	names, err := fs.Glob(migrations, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(names)
+
+	var applied []string
+	for _, name := range names {
+		script, err := fs.ReadFile(migrations, name)
+		if err != nil {
+			return applied, err
+		}
+
+		ok, err := migrate(ctx, client, name, string(script))
+		if err != nil {
+			return applied, fmt.Errorf("migration %s: %w", name, err)
+		}
+		if ok {
+			applied = append(applied, name)
+		}
+	}
+
+	return applied, nil
+}
+
+// migrate runs script unless version is already recorded and reports
+// whether it ran.
+func migrate(ctx context.Context, client *pgxpool.Pool, version, script string) (bool, error) {
+	tx, err := client.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLock); err != nil {
+		return false, err
+	}
+
+	var done bool
+	q := `SELECT EXISTS (SELECT 1 FROM schema_migration WHERE version = $1)`
+	if err := tx.QueryRow(ctx, q, version).Scan(&done); err != nil {
+		return false, err
+	}
+	if done {
+		return false, nil
+	}
+
+	// no arguments: the simple protocol allows several statements
+	if _, err := tx.Exec(ctx, script); err != nil {
+		return false, err
+	}
+
+	if _, err := tx.Exec(ctx, `INSERT INTO schema_migration (version) VALUES ($1)`, version); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit(ctx)
+}
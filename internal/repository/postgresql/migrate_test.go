@@ -0,0 +1,41 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/broadcast80/ozon-task/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	applied, err := Migrate(ctx, pool, db.Migrations)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"001_migration.sql", "002_migration.sql", "003_migration.sql", "004_migration.sql",
+		"005_migration.sql", "006_migration.sql", "007_migration.sql", "008_migration.sql",
+	}, applied)
+
+	applied, err = Migrate(ctx, pool, db.Migrations)
+	require.NoError(t, err)
+	require.Empty(t, applied)
+
+	// a failing migration is rolled back and stops the run
+	broken := fstest.MapFS{
+		"009_migration.sql": {Data: []byte(`CREATE TABLE probe (id INT); SELECT broken;`)},
+		"010_migration.sql": {Data: []byte(`SELECT 1;`)},
+	}
+	applied, err = Migrate(ctx, pool, broken)
+	require.Error(t, err)
+	require.Empty(t, applied)
+
+	var exists bool
+	require.NoError(t, pool.QueryRow(ctx, `SELECT to_regclass('probe') IS NOT NULL`).Scan(&exists))
+	require.False(t, exists)
+}
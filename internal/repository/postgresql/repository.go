@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
 	"github.com/broadcast80/ozon-task/internal/pkg/models"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -19,13 +21,19 @@ func New(client *pgxpool.Pool) *repository {
 	return &repository{client: client}
 }
 
-func (r *repository) Create(ctx context.Context, url string, alias string) error {
+func (r *repository) Create(ctx context.Context, link modellink.Link) error {
 	q := `
-		INSERT INTO link (url, alias) 
-		VALUES ($1, $2)
+		INSERT INTO link (url, alias, expires_at, owner, created_at) 
+		VALUES ($1, $2, $3, $4, COALESCE($5, now()))
 	`
 
-	_, err := r.client.Exec(ctx, q, url, alias)
+	// a zero time falls back to the database clock
+	var createdAt *time.Time
+	if !link.CreatedAt.IsZero() {
+		createdAt = &link.CreatedAt
+	}
+
+	_, err := r.client.Exec(ctx, q, link.URL, link.Alias, link.ExpiresAt, link.Owner, createdAt)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
@@ -48,21 +56,21 @@ func (r *repository) Create(ctx context.Context, url string, alias string) error
 	return nil
 }
 
-func (r *repository) Get(ctx context.Context, alias string) (string, error) {
+func (r *repository) Get(ctx context.Context, alias string) (*modellink.Link, error) {
 	q := `
-		SELECT url
+		SELECT url, expires_at, owner, disabled, created_at
 		FROM link
 		WHERE alias = $1	
 	`
 
-	var url string
+	link := modellink.Link{Alias: alias}
 
 	row := r.client.QueryRow(ctx, q, alias)
 
-	err := row.Scan(&url)
+	err := row.Scan(&link.URL, &link.ExpiresAt, &link.Owner, &link.Disabled, &link.CreatedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return "", models.ErrNotFound
+			return nil, models.ErrNotFound
 		}
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
@@ -75,12 +83,12 @@ func (r *repository) Get(ctx context.Context, alias string) (string, error) {
 				pgErr.Code,
 				pgErr.SQLState(),
 			)
-			return "", newErr
+			return nil, newErr
 		}
-		return "", err
+		return nil, err
 	}
 
-	return url, nil
+	return &link, nil
 }
 
 func (r *repository) URLExists(ctx context.Context, url string) (bool, error) {
@@ -95,3 +103,201 @@ func (r *repository) URLExists(ctx context.Context, url string) (bool, error) {
 
 	return exists, nil
 }
+
+func (r *repository) GetAliasByURL(ctx context.Context, owner, url string) (string, error) {
+	q := `
+		SELECT alias
+		FROM link
+		WHERE owner = $1
+		  AND url = $2
+		  AND NOT disabled
+		  AND (expires_at IS NULL OR expires_at > now())
+		ORDER BY id
+		LIMIT 1
+	`
+
+	var alias string
+
+	err := r.client.QueryRow(ctx, q, owner, url).Scan(&alias)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", models.ErrNotFound
+		}
+		return "", err
+	}
+
+	return alias, nil
+}
+
+// Delete removes the link behind alias together with its hits, which are
+// keyed by alias and would otherwise show up in the stats of a link created
+// later under the same alias.
+func (r *repository) Delete(ctx context.Context, alias string) error {
+	q := `
+		WITH deleted AS (
+			DELETE FROM link
+			WHERE alias = $1
+			RETURNING alias
+		), purged AS (
+			DELETE FROM link_hit
+			WHERE alias IN (SELECT alias FROM deleted)
+		)
+		SELECT count(*) FROM deleted
+	`
+
+	var deleted int64
+	if err := r.client.QueryRow(ctx, q, alias).Scan(&deleted); err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return models.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *repository) SetDisabled(ctx context.Context, alias string, disabled bool) error {
+	q := `
+		UPDATE link
+		SET disabled = $2
+		WHERE alias = $1
+	`
+
+	tag, err := r.client.Exec(ctx, q, alias, disabled)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *repository) Update(ctx context.Context, alias, url string, at time.Time) (*modellink.Link, error) {
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		id       int64
+		previous string
+	)
+
+	err = tx.QueryRow(ctx,
+		`SELECT id, url FROM link WHERE alias = $1 FOR UPDATE`,
+		alias,
+	).Scan(&id, &previous)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ErrNotFound
+		}
+		return nil, err
+	}
+
+	if previous != url {
+		q := `
+			INSERT INTO link_revision (link_id, url, replaced_at)
+			VALUES ($1, $2, $3)
+		`
+		if _, err := tx.Exec(ctx, q, id, previous, at); err != nil {
+			return nil, err
+		}
+	}
+
+	q := `
+		UPDATE link
+		SET url = $2
+		WHERE id = $1
+		RETURNING expires_at, owner, disabled, created_at
+	`
+
+	link := modellink.Link{URL: url, Alias: alias}
+
+	err = tx.QueryRow(ctx, q, id, url).Scan(&link.ExpiresAt, &link.Owner, &link.Disabled, &link.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+func (r *repository) Revisions(ctx context.Context, alias string) ([]models.Revision, error) {
+	// the left join yields a single row of NULLs for a link without
+	// revisions and no rows for a missing one
+	q := `
+		SELECT r.url, r.replaced_at
+		FROM link l
+		LEFT JOIN link_revision r ON r.link_id = l.id
+		WHERE l.alias = $1
+		ORDER BY r.id
+	`
+
+	rows, err := r.client.Query(ctx, q, alias)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		found     bool
+		revisions []models.Revision
+	)
+	for rows.Next() {
+		found = true
+
+		var (
+			url        *string
+			replacedAt *time.Time
+		)
+		if err := rows.Scan(&url, &replacedAt); err != nil {
+			return nil, err
+		}
+		if url != nil {
+			revisions = append(revisions, models.Revision{URL: *url, ReplacedAt: *replacedAt})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, models.ErrNotFound
+	}
+
+	return revisions, nil
+}
+
+// DeleteExpired removes expired links and their hits, see Delete.
+func (r *repository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	q := `
+		WITH deleted AS (
+			DELETE FROM link
+			WHERE expires_at <= $1
+			RETURNING alias
+		), purged AS (
+			DELETE FROM link_hit
+			WHERE alias IN (SELECT alias FROM deleted)
+		)
+		SELECT count(*) FROM deleted
+	`
+
+	var removed int64
+	if err := r.client.QueryRow(ctx, q, now).Scan(&removed); err != nil {
+		return 0, err
+	}
+
+	return removed, nil
+}
+
+func (r *repository) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx)
+}
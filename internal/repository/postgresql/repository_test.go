@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
 	"github.com/broadcast80/ozon-task/internal/pkg/models"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/require"
@@ -37,7 +38,32 @@ func setupTestDB(t *testing.T) (*pgxpool.Pool, func()) {
         CREATE TABLE IF NOT EXISTS link (
             id SERIAL PRIMARY KEY,
             url TEXT NOT NULL,
-            alias TEXT UNIQUE NOT NULL
+            alias TEXT UNIQUE NOT NULL,
+            expires_at TIMESTAMPTZ NULL,
+            owner TEXT NOT NULL DEFAULT '',
+            disabled BOOL NOT NULL DEFAULT false,
+            created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+        );
+        CREATE TABLE IF NOT EXISTS link_revision (
+            id BIGSERIAL PRIMARY KEY,
+            link_id INT NOT NULL REFERENCES link (id) ON DELETE CASCADE,
+            url TEXT NOT NULL,
+            replaced_at TIMESTAMPTZ NOT NULL
+        );
+        CREATE TABLE IF NOT EXISTS api_key (
+            id SERIAL PRIMARY KEY,
+            key_hash TEXT UNIQUE NOT NULL,
+            owner TEXT NOT NULL,
+            admin BOOL NOT NULL DEFAULT false,
+            revoked_at TIMESTAMPTZ NULL
+        );
+        CREATE TABLE IF NOT EXISTS link_hit (
+            id BIGSERIAL PRIMARY KEY,
+            alias TEXT NOT NULL,
+            at TIMESTAMPTZ NOT NULL,
+            referer TEXT NOT NULL DEFAULT '',
+            user_agent TEXT NOT NULL DEFAULT '',
+            ip_hash TEXT NOT NULL DEFAULT ''
         );
     `)
 	require.NoError(t, err)
@@ -57,7 +83,7 @@ func TestRepository_Create_Success(t *testing.T) {
 	repo := New(pool)
 	ctx := context.Background()
 
-	err := repo.Create(ctx, "https://example.com", "test")
+	err := repo.Create(ctx, modellink.Link{URL: "https://example.com", Alias: "test"})
 	require.NoError(t, err)
 
 	var url string
@@ -73,9 +99,9 @@ func TestRepository_Create_Duplicate(t *testing.T) {
 	repo := New(pool)
 	ctx := context.Background()
 
-	require.NoError(t, repo.Create(ctx, "https://example.com", "test"))
+	require.NoError(t, repo.Create(ctx, modellink.Link{URL: "https://example.com", Alias: "test"}))
 
-	err := repo.Create(ctx, "https://example2.com", "test")
+	err := repo.Create(ctx, modellink.Link{URL: "https://example2.com", Alias: "test"})
 	require.ErrorIs(t, err, models.ErrDuplicate)
 }
 
@@ -87,12 +113,13 @@ func TestRepository_Get_Success(t *testing.T) {
 	ctx := context.Background()
 
 	// Создаем запись
-	require.NoError(t, repo.Create(ctx, "https://example.com", "test"))
+	require.NoError(t, repo.Create(ctx, modellink.Link{URL: "https://example.com", Alias: "test"}))
 
 	// Получаем
-	url, err := repo.Get(ctx, "test")
+	link, err := repo.Get(ctx, "test")
 	require.NoError(t, err)
-	require.Equal(t, "https://example.com", url)
+	require.Equal(t, "https://example.com", link.URL)
+	require.Nil(t, link.ExpiresAt)
 }
 
 func TestRepository_Get_NotFound(t *testing.T) {
@@ -102,9 +129,9 @@ func TestRepository_Get_NotFound(t *testing.T) {
 	repo := New(pool)
 	ctx := context.Background()
 
-	url, err := repo.Get(ctx, "nonexistent")
+	link, err := repo.Get(ctx, "nonexistent")
 	require.ErrorIs(t, err, models.ErrNotFound)
-	require.Empty(t, url)
+	require.Nil(t, link)
 }
 
 func TestRepository_URLExists_True(t *testing.T) {
@@ -114,7 +141,7 @@ func TestRepository_URLExists_True(t *testing.T) {
 	repo := New(pool)
 	ctx := context.Background()
 
-	require.NoError(t, repo.Create(ctx, "https://example.com", "test"))
+	require.NoError(t, repo.Create(ctx, modellink.Link{URL: "https://example.com", Alias: "test"}))
 
 	exists, err := repo.URLExists(ctx, "https://example.com")
 	require.NoError(t, err)
@@ -132,3 +159,135 @@ func TestRepository_URLExists_False(t *testing.T) {
 	require.NoError(t, err)
 	require.False(t, exists)
 }
+
+func TestRepository_GetAliasByURL(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := New(pool)
+	ctx := context.Background()
+
+	require.NoError(t, repo.Create(ctx, modellink.Link{URL: "https://example.com", Alias: "test"}))
+
+	alias, err := repo.GetAliasByURL(ctx, "", "https://example.com")
+	require.NoError(t, err)
+	require.Equal(t, "test", alias)
+
+	_, err = repo.GetAliasByURL(ctx, "", "https://nonexistent.com")
+	require.ErrorIs(t, err, models.ErrNotFound)
+}
+
+func TestRepository_Delete(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := New(pool)
+	ctx := context.Background()
+
+	require.NoError(t, repo.Create(ctx, modellink.Link{URL: "https://example.com", Alias: "test"}))
+	require.NoError(t, repo.Delete(ctx, "test"))
+
+	_, err := repo.Get(ctx, "test")
+	require.ErrorIs(t, err, models.ErrNotFound)
+
+	err = repo.Delete(ctx, "test")
+	require.ErrorIs(t, err, models.ErrNotFound)
+}
+
+func TestRepository_SetDisabled(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := New(pool)
+	ctx := context.Background()
+
+	require.NoError(t, repo.Create(ctx, modellink.Link{URL: "https://example.com", Alias: "test"}))
+	require.NoError(t, repo.SetDisabled(ctx, "test", true))
+
+	link, err := repo.Get(ctx, "test")
+	require.NoError(t, err)
+	require.True(t, link.Disabled)
+
+	_, err = repo.GetAliasByURL(ctx, "", "https://example.com")
+	require.ErrorIs(t, err, models.ErrNotFound)
+
+	err = repo.Create(ctx, modellink.Link{URL: "https://other.com", Alias: "test"})
+	require.ErrorIs(t, err, models.ErrDuplicate)
+
+	require.NoError(t, repo.SetDisabled(ctx, "test", false))
+
+	alias, err := repo.GetAliasByURL(ctx, "", "https://example.com")
+	require.NoError(t, err)
+	require.Equal(t, "test", alias)
+
+	err = repo.SetDisabled(ctx, "missing", true)
+	require.ErrorIs(t, err, models.ErrNotFound)
+}
+
+func TestRepository_Update(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := New(pool)
+	ctx := context.Background()
+
+	at := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, repo.Create(ctx, modellink.Link{URL: "https://example.com", Alias: "test", Owner: "grace"}))
+
+	link, err := repo.Update(ctx, "test", "https://example.org", at)
+	require.NoError(t, err)
+	require.Equal(t, "https://example.org", link.URL)
+	require.Equal(t, "grace", link.Owner)
+
+	_, err = repo.Update(ctx, "test", "https://example.org", at.Add(time.Hour))
+	require.NoError(t, err)
+
+	alias, err := repo.GetAliasByURL(ctx, "grace", "https://example.org")
+	require.NoError(t, err)
+	require.Equal(t, "test", alias)
+
+	revisions, err := repo.Revisions(ctx, "test")
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	require.Equal(t, "https://example.com", revisions[0].URL)
+	require.True(t, revisions[0].ReplacedAt.Equal(at))
+
+	_, err = repo.Update(ctx, "missing", "https://example.org", at)
+	require.ErrorIs(t, err, models.ErrNotFound)
+
+	_, err = repo.Revisions(ctx, "missing")
+	require.ErrorIs(t, err, models.ErrNotFound)
+
+	require.NoError(t, repo.Delete(ctx, "test"))
+}
+
+func TestRepository_DeleteExpired(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := New(pool)
+	ctx := context.Background()
+
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	require.NoError(t, repo.Create(ctx, modellink.Link{URL: "https://old.com", Alias: "old", ExpiresAt: &past}))
+	require.NoError(t, repo.Create(ctx, modellink.Link{URL: "https://fresh.com", Alias: "fresh", ExpiresAt: &future}))
+	require.NoError(t, repo.Create(ctx, modellink.Link{URL: "https://forever.com", Alias: "forever"}))
+
+	_, err := repo.GetAliasByURL(ctx, "", "https://old.com")
+	require.ErrorIs(t, err, models.ErrNotFound)
+
+	removed, err := repo.DeleteExpired(ctx, now)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, removed)
+
+	_, err = repo.Get(ctx, "old")
+	require.ErrorIs(t, err, models.ErrNotFound)
+
+	link, err := repo.Get(ctx, "fresh")
+	require.NoError(t, err)
+	require.NotNil(t, link.ExpiresAt)
+}
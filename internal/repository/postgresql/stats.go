@@ -0,0 +1,58 @@
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *repository) RecordHits(ctx context.Context, hits []models.Hit) error {
+	_, err := r.client.CopyFrom(ctx,
+		pgx.Identifier{"link_hit"},
+		[]string{"alias", "at", "referer", "user_agent", "ip_hash"},
+		pgx.CopyFromSlice(len(hits), func(i int) ([]any, error) {
+			hit := hits[i]
+			return []any{hit.Alias, hit.At, hit.Referer, hit.UserAgent, hit.IPHash}, nil
+		}),
+	)
+
+	return err
+}
+
+func (r *repository) GetStats(ctx context.Context, alias string, since time.Time) (*models.Stats, error) {
+	stats := &models.Stats{Alias: alias, Daily: []models.DailyCount{}}
+
+	err := r.client.QueryRow(ctx,
+		`SELECT count(*), max(at) FROM link_hit WHERE alias = $1`,
+		alias,
+	).Scan(&stats.Total, &stats.LastAccessAt)
+	if err != nil {
+		return nil, err
+	}
+
+	q := `
+		SELECT to_char(date_trunc('day', at AT TIME ZONE 'UTC'), 'YYYY-MM-DD') AS day, count(*)
+		FROM link_hit
+		WHERE alias = $1 AND at >= $2
+		GROUP BY day
+		ORDER BY day
+	`
+
+	rows, err := r.client.Query(ctx, q, alias, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var day models.DailyCount
+		if err := rows.Scan(&day.Date, &day.Count); err != nil {
+			return nil, err
+		}
+		stats.Daily = append(stats.Daily, day)
+	}
+
+	return stats, rows.Err()
+}
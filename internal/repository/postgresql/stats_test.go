@@ -0,0 +1,92 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_Stats(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := New(pool)
+	ctx := context.Background()
+
+	day1 := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	day2 := time.Date(2026, 10, 3, 8, 0, 0, 0, time.UTC)
+
+	require.NoError(t, repo.RecordHits(ctx, []models.Hit{
+		{Alias: "abc", At: day1, Referer: "https://news.com", UserAgent: "curl", IPHash: "hash"},
+		{Alias: "abc", At: day2},
+		{Alias: "abc", At: day2.Add(time.Hour)},
+		{Alias: "other", At: day2},
+	}))
+
+	stats, err := repo.GetStats(ctx, "abc", day1)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, stats.Total)
+	require.NotNil(t, stats.LastAccessAt)
+	require.True(t, stats.LastAccessAt.Equal(day2.Add(time.Hour)))
+	require.Equal(t, []models.DailyCount{
+		{Date: "2026-10-01", Count: 1},
+		{Date: "2026-10-03", Count: 2},
+	}, stats.Daily)
+
+	empty, err := repo.GetStats(ctx, "missing", day1)
+	require.NoError(t, err)
+	require.Zero(t, empty.Total)
+	require.Nil(t, empty.LastAccessAt)
+	require.Empty(t, empty.Daily)
+}
+
+func TestRepository_Stats_DeletedAlias(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := New(pool)
+	ctx := context.Background()
+
+	at := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	past := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name   string
+		expire bool
+		remove func(alias string) error
+	}{
+		{name: "delete", remove: func(alias string) error { return repo.Delete(ctx, alias) }},
+		{name: "delete_expired", expire: true, remove: func(alias string) error {
+			_, err := repo.DeleteExpired(ctx, time.Now())
+			return err
+		}},
+		{name: "replace", remove: func(alias string) error {
+			_, err := repo.Replace(ctx, []modellink.Link{{URL: "https://manderlay.com", Alias: alias, Owner: "tom"}})
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link := modellink.Link{URL: "https://dogville.com", Alias: tt.name, Owner: "grace"}
+			if tt.expire {
+				link.ExpiresAt = &past
+			}
+			require.NoError(t, repo.Create(ctx, link))
+			require.NoError(t, repo.RecordHits(ctx, []models.Hit{{Alias: tt.name, At: at}}))
+
+			require.NoError(t, tt.remove(tt.name))
+			// the freed alias starts over for its next owner
+			_ = repo.Create(ctx, modellink.Link{URL: "https://manderlay.com", Alias: tt.name, Owner: "tom"})
+
+			stats, err := repo.GetStats(ctx, tt.name, at)
+			require.NoError(t, err)
+			require.Zero(t, stats.Total)
+			require.Nil(t, stats.LastAccessAt)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+// validateAlias checks a client supplied alias against the configured
+// length range, charset and reserved words.
+func (s *service) validateAlias(alias string) error {
+	if len(alias) < s.aliases.MinLength || len(alias) > s.aliases.MaxLength {
+		return fmt.Errorf(
+			"%w: alias length must be between %d and %d",
+			models.ErrValidation, s.aliases.MinLength, s.aliases.MaxLength,
+		)
+	}
+
+	for _, c := range alias {
+		if !strings.ContainsRune(s.aliases.Charset, c) {
+			return fmt.Errorf("%w: alias contains forbidden character %q", models.ErrValidation, c)
+		}
+	}
+
+	for _, reserved := range s.aliases.Reserved {
+		if strings.EqualFold(alias, reserved) {
+			return fmt.Errorf("%w: alias %q is reserved", models.ErrValidation, alias)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/broadcast80/ozon-task/config"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+// minIPSaltLength is the shortest accepted IP salt.
+const minIPSaltLength = 16
+
+type StatsRepository interface {
+	RecordHits(ctx context.Context, hits []models.Hit) error
+	// GetStats aggregates hits of alias, the daily histogram starts at since.
+	GetStats(ctx context.Context, alias string, since time.Time) (*models.Stats, error)
+}
+
+// analytics buffers hits in memory and writes them to the stats repository
+// in batches, so resolving a link never waits for the stats store.
+type analytics struct {
+	repository StatsRepository
+	cfg        config.AnalyticsConfig
+	logger     *slog.Logger
+
+	hits chan models.Hit
+	done chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+func NewAnalytics(repository StatsRepository, cfg config.AnalyticsConfig, logger *slog.Logger) *analytics {
+	return &analytics{
+		repository: repository,
+		cfg:        cfg,
+		logger:     logger,
+		hits:       make(chan models.Hit, cfg.BufferSize),
+		done:       make(chan struct{}),
+	}
+}
+
+// Record enqueues a hit without blocking. The hit is dropped when the
+// buffer is full. clientIP is only stored as a salted hash.
+func (a *analytics) Record(hit models.Hit, clientIP string) {
+	hit.IPHash = a.hashIP(clientIP)
+
+	select {
+	case a.hits <- hit:
+	default:
+		a.logger.Warn("analytics buffer is full, hit dropped", "alias", hit.Alias)
+	}
+}
+
+func (a *analytics) Stats(ctx context.Context, alias string) (*models.Stats, error) {
+	since := time.Now().UTC().AddDate(0, 0, -a.cfg.HistogramDays+1).Truncate(24 * time.Hour)
+
+	stats, err := a.repository.GetStats(ctx, alias, since)
+	if err != nil {
+		a.logger.Error(err.Error())
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// Start runs the writer goroutine until Stop is called.
+func (a *analytics) Start() {
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+
+		ticker := time.NewTicker(a.cfg.FlushInterval)
+		defer ticker.Stop()
+
+		batch := make([]models.Hit, 0, a.cfg.BatchSize)
+		for {
+			select {
+			case hit := <-a.hits:
+				batch = append(batch, hit)
+				if len(batch) >= a.cfg.BatchSize {
+					batch = a.flush(batch)
+				}
+			case <-ticker.C:
+				batch = a.flush(batch)
+			case <-a.done:
+				for {
+					select {
+					case hit := <-a.hits:
+						batch = append(batch, hit)
+					default:
+						a.flush(batch)
+						return
+					}
+				}
+			}
+		}
+	}()
+}
+
+// Stop flushes buffered hits and waits for the writer to exit.
+func (a *analytics) Stop() {
+	a.once.Do(func() { close(a.done) })
+	a.wg.Wait()
+}
+
+// ValidateIPSalt rejects a salt too short to keep client IPs anonymous. The
+// whole IPv4 space can be hashed in minutes, only a secret salt stops the
+// hashes from being reversed.
+func ValidateIPSalt(salt string) error {
+	if len(salt) < minIPSaltLength {
+		return fmt.Errorf("ip salt must be at least %d characters, set ANALYTICS_IP_SALT", minIPSaltLength)
+	}
+	return nil
+}
+
+func (a *analytics) hashIP(ip string) string {
+	sum := sha256.Sum256([]byte(a.cfg.IPSalt + ip))
+	return hex.EncodeToString(sum[:])
+}
+
+func (a *analytics) flush(batch []models.Hit) []models.Hit {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	if err := a.repository.RecordHits(context.Background(), batch); err != nil {
+		a.logger.Error("failed to record hits", "count", len(batch), "Error", err.Error())
+	}
+
+	return batch[:0]
+}
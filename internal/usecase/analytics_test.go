@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/broadcast80/ozon-task/config"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+type statsMock struct {
+	mu      sync.Mutex
+	batches [][]models.Hit
+	since   time.Time
+}
+
+func (m *statsMock) RecordHits(ctx context.Context, hits []models.Hit) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.batches = append(m.batches, append([]models.Hit(nil), hits...))
+	return nil
+}
+
+func (m *statsMock) GetStats(ctx context.Context, alias string, since time.Time) (*models.Stats, error) {
+	m.since = since
+	return &models.Stats{Alias: alias}, nil
+}
+
+func (m *statsMock) recorded() []models.Hit {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var hits []models.Hit
+	for _, batch := range m.batches {
+		hits = append(hits, batch...)
+	}
+	return hits
+}
+
+func TestAnalytics_FlushesOnStop(t *testing.T) {
+	var logBuf bytes.Buffer
+
+	repo := &statsMock{}
+	a := NewAnalytics(repo, config.AnalyticsConfig{
+		BufferSize:    10,
+		BatchSize:     100,
+		FlushInterval: time.Hour,
+		IPSalt:        "salt",
+	}, testLogger(&logBuf))
+	a.Start()
+
+	a.Record(models.Hit{Alias: "abc", At: time.Now()}, "10.0.0.1")
+	a.Record(models.Hit{Alias: "abc", At: time.Now()}, "10.0.0.1")
+
+	a.Stop()
+
+	hits := repo.recorded()
+	if len(hits) != 2 {
+		t.Fatalf("expected 2 hits, got %d", len(hits))
+	}
+
+	if hits[0].IPHash == "" || hits[0].IPHash == "10.0.0.1" {
+		t.Fatalf("expected hashed client IP, got %q", hits[0].IPHash)
+	}
+
+	if hits[0].IPHash != hits[1].IPHash {
+		t.Fatalf("expected stable IP hash")
+	}
+}
+
+func TestAnalytics_FlushesFullBatch(t *testing.T) {
+	var logBuf bytes.Buffer
+
+	repo := &statsMock{}
+	a := NewAnalytics(repo, config.AnalyticsConfig{
+		BufferSize:    10,
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+	}, testLogger(&logBuf))
+	a.Start()
+	defer a.Stop()
+
+	a.Record(models.Hit{Alias: "abc"}, "10.0.0.1")
+	a.Record(models.Hit{Alias: "abc"}, "10.0.0.2")
+
+	deadline := time.Now().Add(time.Second)
+	for len(repo.recorded()) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("full batch was not flushed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAnalytics_DropsWhenBufferFull(t *testing.T) {
+	var logBuf bytes.Buffer
+
+	repo := &statsMock{}
+	a := NewAnalytics(repo, config.AnalyticsConfig{
+		BufferSize:    1,
+		BatchSize:     10,
+		FlushInterval: time.Hour,
+	}, testLogger(&logBuf))
+
+	a.Record(models.Hit{Alias: "abc"}, "10.0.0.1")
+	a.Record(models.Hit{Alias: "abc"}, "10.0.0.1")
+
+	a.Start()
+	a.Stop()
+
+	if hits := repo.recorded(); len(hits) != 1 {
+		t.Fatalf("expected 1 hit, got %d", len(hits))
+	}
+
+	if logBuf.Len() == 0 {
+		t.Fatalf("expected log output, got empty")
+	}
+}
+
+func TestAnalytics_StatsHistogramWindow(t *testing.T) {
+	var logBuf bytes.Buffer
+
+	repo := &statsMock{}
+	a := NewAnalytics(repo, config.AnalyticsConfig{BufferSize: 1, HistogramDays: 7}, testLogger(&logBuf))
+
+	if _, err := a.Stats(context.Background(), "abc"); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	if want := today.AddDate(0, 0, -6); !repo.since.Equal(want) {
+		t.Fatalf("expected since %v, got %v", want, repo.since)
+	}
+}
+
+func TestValidateIPSalt(t *testing.T) {
+	for _, salt := range []string{"", "short"} {
+		if err := ValidateIPSalt(salt); err == nil {
+			t.Errorf("expected salt %q to be rejected", salt)
+		}
+	}
+
+	if err := ValidateIPSalt("a-long-random-secret"); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+	"github.com/broadcast80/ozon-task/internal/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var batchSizeKey = attribute.Key("batch.size")
+
+// urlKey identifies a URL shortened by one owner.
+type urlKey struct {
+	owner string
+	url   string
+}
+
+// GetAliases shortens a batch with one lookup per owner and one insert per
+// generation attempt instead of a round-trip per link. Links without an
+// alias reuse the alias of an already shortened URL, and repeated URLs in
+// the batch share one alias.
+func (s *service) GetAliases(ctx context.Context, links []modellink.Link) (_ []modellink.BatchResult, err error) {
+	ctx, span := tracer.Start(ctx, "service.GetAliases")
+	span.SetAttributes(batchSizeKey.Int(len(links)))
+	defer func() { tracing.End(span, err) }()
+
+	stored := slices.Clone(links)
+	results := make([]modellink.BatchResult, len(links))
+
+	now := time.Now()
+	for i := range stored {
+		if stored[i].CreatedAt.IsZero() {
+			stored[i].CreatedAt = now
+		}
+	}
+
+	var (
+		// claimed holds the aliases already used in this batch
+		claimed   = make(map[string]bool)
+		custom    []int
+		generated []int
+		first     = make(map[urlKey]int)
+		shared    = make(map[int]int)
+		byOwner   = make(map[string][]string)
+	)
+
+	for i, link := range stored {
+		if link.Alias != "" {
+			if err := s.validateAlias(link.Alias); err != nil {
+				results[i].Err = err
+				continue
+			}
+			if claimed[link.Alias] {
+				results[i].Err = fmt.Errorf("%w: %s", models.ErrAliasTaken, link.Alias)
+				continue
+			}
+			claimed[link.Alias] = true
+			custom = append(custom, i)
+			continue
+		}
+
+		key := urlKey{owner: link.Owner, url: link.URL}
+		if j, ok := first[key]; ok {
+			shared[i] = j
+			continue
+		}
+		first[key] = i
+		generated = append(generated, i)
+		byOwner[link.Owner] = append(byOwner[link.Owner], link.URL)
+	}
+
+	for owner, urls := range byOwner {
+		existing, err := s.repository.GetLinksByURL(ctx, owner, urls)
+		if err != nil {
+			s.log(ctx).Error(err.Error())
+			return nil, err
+		}
+		for url, link := range existing {
+			results[first[urlKey{owner: owner, url: url}]].Link = &link
+		}
+	}
+
+	generated = slices.DeleteFunc(generated, func(i int) bool {
+		return results[i].Link != nil
+	})
+
+	pending := custom
+	for attempt := 0; attempt < maxGenerateAttempts && len(pending)+len(generated) > 0; attempt++ {
+		var retry []int
+		for _, i := range generated {
+			alias := s.generator.Generate(stored[i].URL, attempt)
+			if claimed[alias] {
+				aliasCollisions.Inc()
+				retry = append(retry, i)
+				continue
+			}
+			claimed[alias] = true
+			stored[i].Alias = alias
+			pending = append(pending, i)
+		}
+
+		if len(pending) == 0 {
+			generated = retry
+			continue
+		}
+
+		batch := make([]modellink.Link, len(pending))
+		for k, i := range pending {
+			batch[k] = stored[i]
+		}
+
+		errs, err := s.repository.CreateBatch(ctx, batch)
+		if err != nil {
+			s.log(ctx).Error(err.Error())
+			return nil, err
+		}
+
+		for k, i := range pending {
+			isCustom := links[i].Alias != ""
+
+			switch {
+			case errs[k] == nil:
+				link := stored[i]
+				results[i] = modellink.BatchResult{Link: &link, Created: true}
+				if isCustom {
+					linksCreated.With(sourceCustom).Inc()
+				} else {
+					linksCreated.With(sourceGenerated).Inc()
+				}
+			case errors.Is(errs[k], models.ErrDuplicate) && isCustom:
+				results[i].Err = fmt.Errorf("%w: %s", models.ErrAliasTaken, stored[i].Alias)
+			case errors.Is(errs[k], models.ErrDuplicate):
+				aliasCollisions.Inc()
+				retry = append(retry, i)
+			default:
+				results[i].Err = errs[k]
+			}
+		}
+
+		pending = nil
+		generated = retry
+	}
+
+	for _, i := range generated {
+		results[i].Err = fmt.Errorf("failed to generate unique alias after %d attempts", maxGenerateAttempts)
+	}
+
+	for i, j := range shared {
+		results[i] = modellink.BatchResult{Link: results[j].Link, Err: results[j].Err}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/generator"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+)
+
+func TestService_GetAliases(t *testing.T) {
+	var logBuf bytes.Buffer
+
+	var lookups [][]string
+	repo := &repoMock{
+		GetLinksByURLFn: func(ctx context.Context, owner string, urls []string) (map[string]modellink.Link, error) {
+			lookups = append(lookups, urls)
+			return map[string]modellink.Link{
+				"https://old.com": {URL: "https://old.com", Alias: "old"},
+			}, nil
+		},
+		CreateBatchFn: func(ctx context.Context, links []modellink.Link) ([]error, error) {
+			errs := make([]error, len(links))
+			for i, link := range links {
+				if link.Alias == "taken" {
+					errs[i] = models.ErrDuplicate
+				}
+			}
+			return errs, nil
+		},
+	}
+
+	s := New(repo, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
+
+	results, err := s.GetAliases(context.Background(), []modellink.Link{
+		{URL: "https://new.com"},
+		{URL: "https://old.com"},
+		{URL: "https://new.com"},
+		{URL: "https://promo.com", Alias: "promo"},
+		{URL: "https://other.com", Alias: "promo"},
+		{URL: "https://taken.com", Alias: "taken"},
+		{URL: "https://bad.com", Alias: "x"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if len(results) != 7 {
+		t.Fatalf("want 7 results, got %d", len(results))
+	}
+
+	if results[0].Err != nil || !results[0].Created || results[0].Link.Alias == "" {
+		t.Fatalf("expected a created link, got %+v", results[0])
+	}
+	if results[1].Err != nil || results[1].Created || results[1].Link.Alias != "old" {
+		t.Fatalf("expected the existing alias to be reused, got %+v", results[1])
+	}
+	if results[2].Created || results[2].Link == nil || results[2].Link.Alias != results[0].Link.Alias {
+		t.Fatalf("expected a repeated URL to share the first alias, got %+v", results[2])
+	}
+	if !results[3].Created || results[3].Link.Alias != "promo" {
+		t.Fatalf("expected the custom alias to be created, got %+v", results[3])
+	}
+	if !errors.Is(results[4].Err, models.ErrAliasTaken) {
+		t.Fatalf("expected %v for an alias claimed twice, got %v", models.ErrAliasTaken, results[4].Err)
+	}
+	if !errors.Is(results[5].Err, models.ErrAliasTaken) {
+		t.Fatalf("expected %v for a taken alias, got %v", models.ErrAliasTaken, results[5].Err)
+	}
+	if !errors.Is(results[6].Err, models.ErrValidation) {
+		t.Fatalf("expected %v for an invalid alias, got %v", models.ErrValidation, results[6].Err)
+	}
+
+	if len(lookups) != 1 || len(lookups[0]) != 2 {
+		t.Fatalf("expected one lookup of both unique URLs, got %v", lookups)
+	}
+	if repo.batchCalls != 1 {
+		t.Fatalf("CreateBatch calls: want 1, got %d", repo.batchCalls)
+	}
+}
+
+func TestService_GetAliases_RetriesCollisions(t *testing.T) {
+	var logBuf bytes.Buffer
+
+	var sizes []int
+	repo := &repoMock{
+		GetLinksByURLFn: func(ctx context.Context, owner string, urls []string) (map[string]modellink.Link, error) {
+			return nil, nil
+		},
+		CreateBatchFn: func(ctx context.Context, links []modellink.Link) ([]error, error) {
+			sizes = append(sizes, len(links))
+			errs := make([]error, len(links))
+			if len(sizes) == 1 {
+				errs[0] = models.ErrDuplicate
+			}
+			return errs, nil
+		},
+	}
+
+	s := New(repo, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
+
+	collisionsBefore := aliasCollisions.Value()
+
+	results, err := s.GetAliases(context.Background(), []modellink.Link{
+		{URL: "https://a.com"},
+		{URL: "https://b.com"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	for i, result := range results {
+		if result.Err != nil || !result.Created {
+			t.Fatalf("result %d: expected a created link, got %+v", i, result)
+		}
+	}
+
+	if len(sizes) != 2 || sizes[0] != 2 || sizes[1] != 1 {
+		t.Fatalf("expected only the colliding link to be retried, got batch sizes %v", sizes)
+	}
+
+	if got := aliasCollisions.Value() - collisionsBefore; got != 1 {
+		t.Fatalf("collision retries: want 1, got %v", got)
+	}
+}
+
+func TestService_GetAliases_RepositoryError(t *testing.T) {
+	var logBuf bytes.Buffer
+
+	repoErr := errors.New("db down")
+	repo := &repoMock{
+		GetLinksByURLFn: func(ctx context.Context, owner string, urls []string) (map[string]modellink.Link, error) {
+			return nil, nil
+		},
+		CreateBatchFn: func(ctx context.Context, links []modellink.Link) ([]error, error) {
+			return nil, repoErr
+		},
+	}
+
+	s := New(repo, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
+
+	_, err := s.GetAliases(context.Background(), []modellink.Link{{URL: "https://a.com"}})
+	if !errors.Is(err, repoErr) {
+		t.Fatalf("expected %v, got %v", repoErr, err)
+	}
+}
@@ -0,0 +1,20 @@
+package usecase
+
+import "github.com/broadcast80/ozon-task/internal/pkg/metrics"
+
+const (
+	sourceGenerated = "generated"
+	sourceCustom    = "custom"
+)
+
+var (
+	linksCreated = metrics.Default.NewCounterVec(
+		"shortener_links_created_total",
+		"Links stored, by whether the alias was generated or chosen by the client.",
+		"source",
+	)
+	aliasCollisions = metrics.Default.NewCounter(
+		"shortener_alias_collisions_total",
+		"Generated aliases that were already taken and had to be retried.",
+	)
+)
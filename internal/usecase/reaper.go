@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// reaper periodically purges expired links from the repository.
+type reaper struct {
+	repository RepositoryInterface
+	interval   time.Duration
+	logger     *slog.Logger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewReaper(repository RepositoryInterface, interval time.Duration, logger *slog.Logger) *reaper {
+	return &reaper{
+		repository: repository,
+		interval:   interval,
+		logger:     logger,
+	}
+}
+
+// Start runs the reaper in a background goroutine until Stop is called.
+func (r *reaper) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.Reap(ctx)
+			}
+		}
+	}()
+}
+
+// Reap runs a single purge pass and returns how many links it removed.
+func (r *reaper) Reap(ctx context.Context) (int64, error) {
+	removed, err := r.repository.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		r.logger.Error("failed to delete expired links", "Error", err.Error())
+		return 0, err
+	}
+
+	if removed > 0 {
+		r.logger.Info("deleted expired links", "count", removed)
+	}
+
+	return removed, nil
+}
+
+// Stop cancels the reaper and waits for the current pass to finish.
+func (r *reaper) Stop() {
+	if r.cancel == nil {
+		return
+	}
+
+	r.cancel()
+	r.wg.Wait()
+}
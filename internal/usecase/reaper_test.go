@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReaper_PurgesUntilStopped(t *testing.T) {
+	var logBuf bytes.Buffer
+
+	reaped := make(chan time.Time, 10)
+	repo := &repoMock{
+		DeleteExpiredFn: func(ctx context.Context, now time.Time) (int64, error) {
+			reaped <- now
+			return 1, nil
+		},
+	}
+
+	r := NewReaper(repo, 5*time.Millisecond, testLogger(&logBuf))
+	r.Start()
+
+	select {
+	case <-reaped:
+	case <-time.After(time.Second):
+		t.Fatal("reaper did not run")
+	}
+
+	r.Stop()
+
+	calls := len(reaped)
+	time.Sleep(20 * time.Millisecond)
+	if len(reaped) != calls {
+		t.Fatal("reaper kept running after Stop")
+	}
+}
+
+func TestReaper_Reap_Error(t *testing.T) {
+	var logBuf bytes.Buffer
+
+	repo := &repoMock{
+		DeleteExpiredFn: func(ctx context.Context, now time.Time) (int64, error) {
+			return 0, errors.New("delete failed")
+		},
+	}
+
+	if _, err := NewReaper(repo, time.Minute, testLogger(&logBuf)).Reap(context.Background()); err == nil {
+		t.Fatalf("expected the repository error")
+	}
+
+	if logBuf.Len() == 0 {
+		t.Fatalf("expected log output, got empty")
+	}
+}
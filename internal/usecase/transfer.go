@@ -0,0 +1,216 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	domainlink "github.com/broadcast80/ozon-task/domain/link"
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/auth"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+	"github.com/broadcast80/ozon-task/internal/pkg/tracing"
+	"github.com/broadcast80/ozon-task/internal/pkg/transfer"
+	"golang.org/x/sync/errgroup"
+)
+
+// transferBatchSize is the page size of an export and the number of
+// records stored at once by an import.
+const transferBatchSize = 500
+
+// importChecks bounds concurrent destination checks of an import batch,
+// each may resolve a host.
+const importChecks = 16
+
+// ExportLinks writes every stored link to w, oldest first, page by page,
+// and returns how many were written. Revisions are not exported.
+func (s *service) ExportLinks(ctx context.Context, w transfer.Writer) (n int, err error) {
+	ctx, span := tracer.Start(ctx, "service.ExportLinks")
+	defer func() { tracing.End(span, err) }()
+
+	if err := requireAdmin(ctx); err != nil {
+		return 0, err
+	}
+
+	filter := modellink.Filter{Ascending: true, Limit: transferBatchSize}
+	for {
+		page, err := s.repository.List(ctx, filter)
+		if err != nil {
+			s.log(ctx).Error(err.Error())
+			return n, err
+		}
+
+		for _, link := range page.Links {
+			if err := w.Write(link); err != nil {
+				return n, err
+			}
+			n++
+		}
+
+		if page.Next == nil {
+			break
+		}
+		filter.After = page.Next
+	}
+
+	return n, w.Flush()
+}
+
+// ImportLinks validates and stores the records of r in batches, resolving
+// taken aliases by policy. It stops at the first invalid record, one
+// repeating the alias of an earlier record included, at the
+// batch holding the first rejected destination or, with transfer.Fail,
+// after the batch holding the first conflict, whose other records are
+// stored. Nothing is rolled back, result counts every stored record.
+func (s *service) ImportLinks(ctx context.Context, r transfer.Reader, policy transfer.Policy) (result transfer.Result, err error) {
+	ctx, span := tracer.Start(ctx, "service.ImportLinks")
+	defer func() { tracing.End(span, err) }()
+
+	if err := requireAdmin(ctx); err != nil {
+		return result, err
+	}
+
+	var (
+		batch = make([]modellink.Link, 0, transferBatchSize)
+		// first is the number of the first record in batch
+		first = 1
+		now   = time.Now()
+		// seen maps imported aliases to their record, an export never
+		// repeats one
+		seen = make(map[string]int)
+	)
+	for n := 1; ; n++ {
+		link, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err == nil {
+			err = s.validateRecord(&link, now)
+		}
+		if previous, ok := seen[link.Alias]; ok && err == nil {
+			err = fmt.Errorf("%w: alias %s repeats record %d", models.ErrValidation, link.Alias, previous)
+		}
+		if err != nil {
+			return result, fmt.Errorf("record %d: %w", n, err)
+		}
+		seen[link.Alias] = n
+
+		batch = append(batch, link)
+		if len(batch) < transferBatchSize {
+			continue
+		}
+
+		if err := s.importBatch(ctx, batch, first, policy, &result); err != nil {
+			return result, err
+		}
+		batch = batch[:0]
+		first = n + 1
+	}
+
+	if len(batch) > 0 {
+		if err := s.importBatch(ctx, batch, first, policy, &result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// validateRecord checks an imported link like a custom alias on create and
+// normalizes its URL. Destinations are checked per batch, see
+// checkDestinations.
+func (s *service) validateRecord(link *modellink.Link, now time.Time) error {
+	if err := s.validateAlias(link.Alias); err != nil {
+		return err
+	}
+
+	url, err := domainlink.NormalizeURL(link.URL)
+	if err != nil {
+		return err
+	}
+	link.URL = url
+
+	if link.CreatedAt.IsZero() {
+		link.CreatedAt = now
+	}
+
+	return nil
+}
+
+// checkDestinations checks the URLs of batch concurrently and returns the
+// error of the first rejected record.
+func (s *service) checkDestinations(ctx context.Context, batch []modellink.Link, first int) error {
+	if s.destinations == nil {
+		return nil
+	}
+
+	errs := make([]error, len(batch))
+
+	var group errgroup.Group
+	group.SetLimit(importChecks)
+	for i := range batch {
+		group.Go(func() error {
+			errs[i] = s.destinations.Check(ctx, batch[i].URL)
+			return nil
+		})
+	}
+	group.Wait()
+
+	for k, err := range errs {
+		if err != nil {
+			return fmt.Errorf("record %d: %w", first+k, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *service) importBatch(ctx context.Context, batch []modellink.Link, first int, policy transfer.Policy, result *transfer.Result) error {
+	if err := s.checkDestinations(ctx, batch, first); err != nil {
+		return err
+	}
+
+	store := s.repository.CreateBatch
+	if policy == transfer.Overwrite {
+		store = s.repository.Replace
+	}
+
+	errs, err := store(ctx, batch)
+	if err != nil {
+		s.log(ctx).Error(err.Error())
+		return err
+	}
+
+	var conflict error
+	for k, err := range errs {
+		switch {
+		case err == nil:
+			result.Imported++
+		case errors.Is(err, models.ErrDuplicate) && policy == transfer.Skip:
+			result.Skipped++
+		case errors.Is(err, models.ErrDuplicate):
+			if conflict == nil {
+				conflict = fmt.Errorf("record %d: %w: %s", first+k, models.ErrAliasTaken, batch[k].Alias)
+			}
+		default:
+			return fmt.Errorf("record %d: %w", first+k, err)
+		}
+	}
+
+	return conflict
+}
+
+// requireAdmin rejects callers without an admin key, anonymous ones
+// included.
+func requireAdmin(ctx context.Context) error {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return fmt.Errorf("%w: admin key required", models.ErrUnauthorized)
+	}
+	if !principal.Admin {
+		return fmt.Errorf("%w: admin key required", models.ErrForbidden)
+	}
+	return nil
+}
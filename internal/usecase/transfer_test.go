@@ -0,0 +1,271 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/auth"
+	"github.com/broadcast80/ozon-task/internal/pkg/generator"
+	"github.com/broadcast80/ozon-task/internal/pkg/models"
+	"github.com/broadcast80/ozon-task/internal/pkg/transfer"
+)
+
+func adminCtx() context.Context {
+	return auth.WithPrincipal(context.Background(), models.Principal{Owner: "admin", Admin: true})
+}
+
+func TestService_ExportLinks(t *testing.T) {
+	var logBuf bytes.Buffer
+
+	createdAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	next := modellink.Cursor{CreatedAt: createdAt, ID: 1}
+
+	var filters []modellink.Filter
+	repo := &repoMock{
+		ListFn: func(ctx context.Context, filter modellink.Filter) (*modellink.Page, error) {
+			filters = append(filters, filter)
+			if filter.After == nil {
+				return &modellink.Page{
+					Links: []modellink.Link{{URL: "https://a.com", Alias: "a", CreatedAt: createdAt}},
+					Next:  &next,
+				}, nil
+			}
+			return &modellink.Page{
+				Links: []modellink.Link{{URL: "https://b.com", Alias: "b", CreatedAt: createdAt}},
+			}, nil
+		},
+	}
+
+	s := New(repo, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
+
+	var out bytes.Buffer
+	n, err := s.ExportLinks(adminCtx(), transfer.NewWriter(&out, transfer.JSONL))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if n != 2 || strings.Count(out.String(), "\n") != 2 {
+		t.Fatalf("expected 2 exported links, got %d:\n%s", n, out.String())
+	}
+
+	if len(filters) != 2 || !filters[0].Ascending || filters[0].Limit != transferBatchSize || filters[1].After != &next {
+		t.Fatalf("expected two ascending pages, got %+v", filters)
+	}
+}
+
+func TestService_Transfer_AdminOnly(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		wantErr error
+	}{
+		{name: "anonymous", ctx: context.Background(), wantErr: models.ErrUnauthorized},
+		{name: "owner", ctx: auth.WithPrincipal(context.Background(), models.Principal{Owner: "grace"}), wantErr: models.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logBuf bytes.Buffer
+
+			s := New(&repoMock{}, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
+
+			if _, err := s.ExportLinks(tt.ctx, transfer.NewWriter(&bytes.Buffer{}, transfer.JSONL)); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("export: expected %v, got %v", tt.wantErr, err)
+			}
+
+			r := transfer.NewReader(strings.NewReader(`{"alias":"abc","url":"https://a.com"}`), transfer.JSONL)
+			if _, err := s.ImportLinks(tt.ctx, r, transfer.Fail); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("import: expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// conflictRepo stores links in a map and reports taken aliases like the
+// real repositories.
+func conflictRepo(taken ...string) (*repoMock, map[string]modellink.Link) {
+	stored := make(map[string]modellink.Link)
+	for _, alias := range taken {
+		stored[alias] = modellink.Link{Alias: alias, URL: "https://stored.com"}
+	}
+
+	repo := &repoMock{
+		CreateBatchFn: func(ctx context.Context, links []modellink.Link) ([]error, error) {
+			errs := make([]error, len(links))
+			for i, link := range links {
+				if _, ok := stored[link.Alias]; ok {
+					errs[i] = models.ErrDuplicate
+					continue
+				}
+				stored[link.Alias] = link
+			}
+			return errs, nil
+		},
+		ReplaceFn: func(ctx context.Context, links []modellink.Link) ([]error, error) {
+			for _, link := range links {
+				stored[link.Alias] = link
+			}
+			return make([]error, len(links)), nil
+		},
+	}
+
+	return repo, stored
+}
+
+func TestService_ImportLinks(t *testing.T) {
+	input := `{"alias":"abc","url":"HTTPS://A.com","owner":"grace","created_at":"2030-01-01T00:00:00Z"}
+{"alias":"taken","url":"https://b.com"}
+{"alias":"def","url":"https://c.com"}
+`
+
+	tests := []struct {
+		policy    transfer.Policy
+		wantErr   error
+		want      transfer.Result
+		wantTaken string
+	}{
+		{policy: transfer.Skip, want: transfer.Result{Imported: 2, Skipped: 1}, wantTaken: "https://stored.com"},
+		{policy: transfer.Overwrite, want: transfer.Result{Imported: 3}, wantTaken: "https://b.com/"},
+		{policy: transfer.Fail, wantErr: models.ErrAliasTaken, want: transfer.Result{Imported: 2}, wantTaken: "https://stored.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			var logBuf bytes.Buffer
+
+			repo, stored := conflictRepo("taken")
+			s := New(repo, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
+
+			result, err := s.ImportLinks(adminCtx(), transfer.NewReader(strings.NewReader(input), transfer.JSONL), tt.policy)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if err != nil && !strings.Contains(err.Error(), "record 2") {
+				t.Errorf("expected the error to name record 2, got %v", err)
+			}
+
+			if result != tt.want {
+				t.Errorf("result = %+v, want %+v", result, tt.want)
+			}
+
+			if stored["taken"].URL != tt.wantTaken {
+				t.Errorf("taken alias points to %q, want %q", stored["taken"].URL, tt.wantTaken)
+			}
+
+			link := stored["abc"]
+			if link.URL != "https://a.com/" || link.Owner != "grace" || !link.CreatedAt.Equal(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)) {
+				t.Errorf("unexpected imported link %+v", link)
+			}
+			if stored["def"].CreatedAt.IsZero() {
+				t.Errorf("expected a missing created_at to be set")
+			}
+		})
+	}
+}
+
+func TestService_ImportLinks_InvalidRecord(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "reserved_alias", input: `{"alias":"api","url":"https://a.com"}`},
+		{name: "invalid_url", input: `{"alias":"abc","url":"ftp://a.com"}`},
+		{name: "malformed", input: `{"alias":`},
+		{name: "repeated_alias", input: `{"alias":"first","url":"https://second.com"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logBuf bytes.Buffer
+
+			repo, stored := conflictRepo()
+			s := New(repo, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
+
+			input := `{"alias":"first","url":"https://first.com"}` + "\n" + tt.input
+			_, err := s.ImportLinks(adminCtx(), transfer.NewReader(strings.NewReader(input), transfer.JSONL), transfer.Fail)
+			if !errors.Is(err, models.ErrValidation) || !strings.Contains(err.Error(), "record 2") {
+				t.Fatalf("expected a validation error for record 2, got %v", err)
+			}
+
+			if len(stored) != 0 {
+				t.Errorf("expected nothing to be stored, got %v", stored)
+			}
+		})
+	}
+}
+
+func TestService_ImportLinks_Batches(t *testing.T) {
+	var logBuf bytes.Buffer
+
+	var sizes []int
+	repo, _ := conflictRepo()
+	createBatch := repo.CreateBatchFn
+	repo.CreateBatchFn = func(ctx context.Context, links []modellink.Link) ([]error, error) {
+		sizes = append(sizes, len(links))
+		return createBatch(ctx, links)
+	}
+
+	s := New(repo, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
+
+	var input strings.Builder
+	for i := range transferBatchSize + 1 {
+		fmt.Fprintf(&input, "{\"alias\":\"alias%d\",\"url\":\"https://a.com/%d\"}\n", i, i)
+	}
+
+	result, err := s.ImportLinks(adminCtx(), transfer.NewReader(strings.NewReader(input.String()), transfer.JSONL), transfer.Fail)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if result.Imported != transferBatchSize+1 || len(sizes) != 2 || sizes[0] != transferBatchSize || sizes[1] != 1 {
+		t.Fatalf("expected a full and a partial batch, got %+v and batch sizes %v", result, sizes)
+	}
+}
+
+// checkerFunc adapts a function to domainlink.DestinationChecker.
+type checkerFunc func(ctx context.Context, url string) error
+
+func (f checkerFunc) Check(ctx context.Context, url string) error {
+	return f(ctx, url)
+}
+
+func TestService_ImportLinks_Destinations(t *testing.T) {
+	var logBuf bytes.Buffer
+
+	repo, stored := conflictRepo()
+	s := New(repo, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
+
+	var mu sync.Mutex
+	var checked []string
+	s.SetDestinations(checkerFunc(func(ctx context.Context, url string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		checked = append(checked, url)
+		if strings.Contains(url, "10.0.0.1") {
+			return &models.DestinationError{Reason: "private_address", Host: "10.0.0.1"}
+		}
+		return nil
+	}))
+
+	input := `{"alias":"abc","url":"https://a.com"}
+{"alias":"def","url":"http://10.0.0.1/admin"}
+`
+	_, err := s.ImportLinks(adminCtx(), transfer.NewReader(strings.NewReader(input), transfer.JSONL), transfer.Fail)
+	if !errors.Is(err, models.ErrUnsafeDestination) || !strings.Contains(err.Error(), "record 2") {
+		t.Fatalf("expected an unsafe destination error for record 2, got %v", err)
+	}
+
+	if len(checked) != 2 {
+		t.Errorf("expected both records to be checked, got %v", checked)
+	}
+	if len(stored) != 0 {
+		t.Errorf("expected the batch to be rejected, got %v", stored)
+	}
+}
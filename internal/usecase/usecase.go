@@ -3,68 +3,305 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"time"
 
+	"github.com/broadcast80/ozon-task/config"
+	domainlink "github.com/broadcast80/ozon-task/domain/link"
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/auth"
+	"github.com/broadcast80/ozon-task/internal/pkg/logging"
 	"github.com/broadcast80/ozon-task/internal/pkg/models"
-	"github.com/broadcast80/ozon-task/internal/pkg/utils"
+	"github.com/broadcast80/ozon-task/internal/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_" // использовать хеш функцию и обрубить на 5 элементов, MD5
+const maxGenerateAttempts = 10
+
+var (
+	tracer = otel.Tracer("github.com/broadcast80/ozon-task/internal/usecase")
+
+	reusedKey  = attribute.Key("link.reused")
+	attemptKey = attribute.Key("alias.attempt")
+)
 
 type RepositoryInterface interface {
-	Create(ctx context.Context, url string, alias string) error
-	Get(ctx context.Context, alias string) (string, error)
+	Create(ctx context.Context, link modellink.Link) error
+	Get(ctx context.Context, alias string) (*modellink.Link, error)
 	URLExists(ctx context.Context, url string) (bool, error)
+	// GetAliasByURL returns the alias of a stored, not yet expired link
+	// created by owner.
+	GetAliasByURL(ctx context.Context, owner, url string) (string, error)
+	// GetLinksByURL is the batch form of GetAliasByURL, it returns the
+	// canonical links of owner for urls, keyed by URL.
+	GetLinksByURL(ctx context.Context, owner string, urls []string) (map[string]modellink.Link, error)
+	// CreateBatch stores links at once and returns an error per link,
+	// ErrDuplicate for a taken alias. The returned error means nothing was
+	// stored.
+	CreateBatch(ctx context.Context, links []modellink.Link) ([]error, error)
+	// Replace stores links like CreateBatch, but a link already stored
+	// under the same alias is deleted first, together with its revisions.
+	// When an alias repeats, its last link wins and the earlier ones get
+	// ErrDuplicate.
+	Replace(ctx context.Context, links []modellink.Link) ([]error, error)
+	Delete(ctx context.Context, alias string) error
+	// SetDisabled marks the link behind alias as disabled or enabled again.
+	// Disabled links keep their alias but are skipped by GetAliasByURL.
+	SetDisabled(ctx context.Context, alias string, disabled bool) error
+	// Update points alias to url and records the previous URL as a
+	// revision replaced at the moment at. Updating to the current URL
+	// records nothing.
+	Update(ctx context.Context, alias, url string, at time.Time) (*modellink.Link, error)
+	// Revisions returns the previous URLs of alias, oldest first.
+	Revisions(ctx context.Context, alias string) ([]models.Revision, error)
+	// List returns up to filter.Limit links matching filter, sorted by
+	// creation time, and the cursor of the next page.
+	List(ctx context.Context, filter modellink.Filter) (*modellink.Page, error)
+	// DeleteExpired removes links that expired before now and returns how
+	// many were removed.
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// AliasGenerator produces a candidate alias for url. attempt counts the
+// collisions seen so far for this url, starting from 0.
+type AliasGenerator interface {
+	Generate(url string, attempt int) string
 }
 
 type service struct {
 	repository RepositoryInterface
+	generator  AliasGenerator
 	logger     *slog.Logger
+	aliases    config.AliasConfig
+
+	destinations domainlink.DestinationChecker
 }
 
-func New(repository RepositoryInterface, logger *slog.Logger) *service {
+func New(repository RepositoryInterface, generator AliasGenerator, logger *slog.Logger, aliases config.AliasConfig) *service {
 	return &service{
 		repository: repository,
+		generator:  generator,
 		logger:     logger,
+		aliases:    aliases,
 	}
 }
 
-func (s *service) GetAlias(ctx context.Context, url string) (string, error) {
+// SetDestinations makes imports check the destination of every record
+// like a new link. Without a checker imported URLs are only validated.
+func (s *service) SetDestinations(destinations domainlink.DestinationChecker) {
+	s.destinations = destinations
+}
 
-	found, err := s.repository.URLExists(ctx, url)
-	if err != nil {
-		s.logger.Error(err.Error())
-		return "", err
+// log returns the request-scoped logger when ctx carries one.
+func (s *service) log(ctx context.Context) *slog.Logger {
+	return logging.FromContext(ctx, s.logger)
+}
+
+func (s *service) GetAlias(ctx context.Context, link modellink.Link) (_ *modellink.Link, _ bool, err error) {
+	ctx, span := tracer.Start(ctx, "service.GetAlias")
+	defer func() { tracing.End(span, err) }()
+
+	if link.CreatedAt.IsZero() {
+		link.CreatedAt = time.Now()
 	}
-	if found {
-		return "", models.ErrDuplicate
+
+	existing, err := s.repository.GetAliasByURL(ctx, link.Owner, link.URL)
+	if err == nil {
+		stored, err := s.repository.Get(ctx, existing)
+		if err == nil {
+			span.SetAttributes(tracing.AliasKey.String(stored.Alias), reusedKey.Bool(true))
+			return stored, false, nil
+		}
+		if !errors.Is(err, models.ErrNotFound) {
+			s.log(ctx).Error(err.Error())
+			return nil, false, err
+		}
+	} else if !errors.Is(err, models.ErrNotFound) {
+		s.log(ctx).Error(err.Error())
+		return nil, false, err
 	}
 
-	alias := utils.Encode(10, charset)
+	for attempt := range maxGenerateAttempts {
+		link.Alias = s.generator.Generate(link.URL, attempt)
 
-	for range 10 {
-		err := s.repository.Create(ctx, url, alias)
+		err := s.repository.Create(ctx, link)
 		if errors.Is(err, models.ErrDuplicate) {
-			alias = utils.Encode(10, charset)
+			aliasCollisions.Inc()
+			span.AddEvent("alias collision", trace.WithAttributes(
+				tracing.AliasKey.String(link.Alias), attemptKey.Int(attempt),
+			))
 			continue
 		} else if err != nil {
-			s.logger.Error(err.Error())
-			return "", err
+			s.log(ctx).Error(err.Error())
+			return nil, false, err
+		}
+
+		linksCreated.With(sourceGenerated).Inc()
+		span.SetAttributes(tracing.AliasKey.String(link.Alias), reusedKey.Bool(false))
+		return &link, true, nil
+	}
+
+	err = fmt.Errorf("failed to generate unique alias after %d attempts", maxGenerateAttempts)
+	s.log(ctx).Error(err.Error())
+	return nil, false, err
+}
+
+func (s *service) CreateAlias(ctx context.Context, link modellink.Link) error {
+
+	if err := s.validateAlias(link.Alias); err != nil {
+		return err
+	}
+
+	if link.CreatedAt.IsZero() {
+		link.CreatedAt = time.Now()
+	}
+
+	err := s.repository.Create(ctx, link)
+	if errors.Is(err, models.ErrDuplicate) {
+		return fmt.Errorf("%w: %s", models.ErrAliasTaken, link.Alias)
+	} else if err != nil {
+		s.log(ctx).Error(err.Error())
+		return err
+	}
+
+	linksCreated.With(sourceCustom).Inc()
+	return nil
+}
+
+func (s *service) GetLink(ctx context.Context, alias string) (*modellink.Link, error) {
+
+	link, err := s.repository.Get(ctx, alias)
+	if err != nil {
+		s.log(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	if link.Disabled {
+		return nil, fmt.Errorf("%w: %s", models.ErrDisabled, alias)
+	}
+
+	if link.Expired(time.Now()) {
+		return nil, fmt.Errorf("%w: %s", models.ErrExpired, alias)
+	}
+
+	return link, nil
+}
+
+func (s *service) DeleteAlias(ctx context.Context, alias string) error {
+
+	if err := s.authorize(ctx, alias); err != nil {
+		return err
+	}
+
+	err := s.repository.Delete(ctx, alias)
+	if err != nil {
+		s.log(ctx).Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (s *service) SetAliasDisabled(ctx context.Context, alias string, disabled bool) error {
+
+	if err := s.authorize(ctx, alias); err != nil {
+		return err
+	}
+
+	err := s.repository.SetDisabled(ctx, alias, disabled)
+	if err != nil {
+		s.log(ctx).Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (s *service) UpdateAlias(ctx context.Context, alias, url string) (*modellink.Link, error) {
+
+	if err := s.authorize(ctx, alias); err != nil {
+		return nil, err
+	}
+
+	link, err := s.repository.Update(ctx, alias, url, time.Now())
+	if err != nil {
+		s.log(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	return link, nil
+}
+
+func (s *service) GetOwnedLink(ctx context.Context, alias string) (*modellink.Link, error) {
+
+	link, err := s.repository.Get(ctx, alias)
+	if err != nil {
+		if !errors.Is(err, models.ErrNotFound) {
+			s.log(ctx).Error(err.Error())
 		}
+		return nil, err
+	}
 
-		break
+	if !auth.CanModify(ctx, link.Owner) {
+		return nil, fmt.Errorf("%w: %s", models.ErrForbidden, alias)
 	}
 
-	return alias, nil
+	return link, nil
 }
 
-func (s *service) GetURL(ctx context.Context, alias string) (string, error) {
+func (s *service) GetRevisions(ctx context.Context, alias string) ([]models.Revision, error) {
+
+	if err := s.authorize(ctx, alias); err != nil {
+		return nil, err
+	}
 
-	url, err := s.repository.Get(ctx, alias)
+	revisions, err := s.repository.Revisions(ctx, alias)
 	if err != nil {
-		s.logger.Error(err.Error())
-		return "", err
+		s.log(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// ListLinks returns a page of links matching filter. Callers without an
+// admin key only see their own links.
+func (s *service) ListLinks(ctx context.Context, filter modellink.Filter) (*modellink.Page, error) {
+
+	if principal, ok := auth.FromContext(ctx); ok && !principal.Admin {
+		if filter.Owner != "" && filter.Owner != principal.Owner {
+			return nil, fmt.Errorf("%w: cannot list links of %s", models.ErrForbidden, filter.Owner)
+		}
+		filter.Owner = principal.Owner
+	}
+
+	page, err := s.repository.List(ctx, filter)
+	if err != nil {
+		s.log(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// authorize checks that the caller in ctx owns the link behind alias or
+// holds an admin key.
+func (s *service) authorize(ctx context.Context, alias string) error {
+	if _, ok := auth.FromContext(ctx); !ok {
+		return nil
+	}
+
+	link, err := s.repository.Get(ctx, alias)
+	if err != nil {
+		return err
+	}
+
+	if !auth.CanModify(ctx, link.Owner) {
+		return fmt.Errorf("%w: %s", models.ErrForbidden, alias)
 	}
 
-	return url, nil
+	return nil
 }
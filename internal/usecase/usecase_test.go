@@ -6,22 +6,48 @@ import (
 	"errors"
 	"log/slog"
 	"testing"
+	"time"
 
+	"github.com/broadcast80/ozon-task/config"
+	modellink "github.com/broadcast80/ozon-task/domain/model/link"
+	"github.com/broadcast80/ozon-task/internal/pkg/auth"
+	"github.com/broadcast80/ozon-task/internal/pkg/generator"
+	"github.com/broadcast80/ozon-task/internal/pkg/logging"
 	"github.com/broadcast80/ozon-task/internal/pkg/models"
 )
 
 type repoMock struct {
 	URLExistsFn func(ctx context.Context, url string) (bool, error)
 	CreateFn    func(ctx context.Context, url, alias string) error
-	GetFn       func(ctx context.Context, alias string) (string, error)
+	GetFn       func(ctx context.Context, alias string) (*modellink.Link, error)
+	DeleteFn    func(ctx context.Context, alias string) error
+	DisableFn   func(ctx context.Context, alias string, disabled bool) error
+	UpdateFn    func(ctx context.Context, alias, url string) (*modellink.Link, error)
+	ListFn      func(ctx context.Context, filter modellink.Filter) (*modellink.Page, error)
+
+	GetLinksByURLFn func(ctx context.Context, owner string, urls []string) (map[string]modellink.Link, error)
+	CreateBatchFn   func(ctx context.Context, links []modellink.Link) ([]error, error)
+	ReplaceFn       func(ctx context.Context, links []modellink.Link) ([]error, error)
+
+	GetAliasByURLFn func(ctx context.Context, url string) (string, error)
+	DeleteExpiredFn func(ctx context.Context, now time.Time) (int64, error)
 
 	urlExistsCalls int
 	createCalls    int
 	getCalls       int
+	deleteCalls    int
+	disableCalls   int
+	updateCalls    int
+	batchCalls     int
+	aliasByURLCall int
+	reapCalls      int
 
 	lastCreateURL   string
 	lastCreateAlias string
+	lastCreateLink  modellink.Link
 	lastGetAlias    string
+
+	lastAliasByURLOwner string
 }
 
 func (m *repoMock) URLExists(ctx context.Context, url string) (bool, error) {
@@ -29,50 +55,133 @@ func (m *repoMock) URLExists(ctx context.Context, url string) (bool, error) {
 	return m.URLExistsFn(ctx, url)
 }
 
-func (m *repoMock) Create(ctx context.Context, url, alias string) error {
+func (m *repoMock) Create(ctx context.Context, link modellink.Link) error {
 	m.createCalls++
-	m.lastCreateURL = url
-	m.lastCreateAlias = alias
-	return m.CreateFn(ctx, url, alias)
+	m.lastCreateURL = link.URL
+	m.lastCreateAlias = link.Alias
+	m.lastCreateLink = link
+	return m.CreateFn(ctx, link.URL, link.Alias)
 }
 
-func (m *repoMock) Get(ctx context.Context, alias string) (string, error) {
+func (m *repoMock) Get(ctx context.Context, alias string) (*modellink.Link, error) {
 	m.getCalls++
 	m.lastGetAlias = alias
 	return m.GetFn(ctx, alias)
 }
 
+func (m *repoMock) Delete(ctx context.Context, alias string) error {
+	m.deleteCalls++
+	return m.DeleteFn(ctx, alias)
+}
+
+func (m *repoMock) SetDisabled(ctx context.Context, alias string, disabled bool) error {
+	m.disableCalls++
+	return m.DisableFn(ctx, alias, disabled)
+}
+
+func (m *repoMock) Update(ctx context.Context, alias, url string, at time.Time) (*modellink.Link, error) {
+	m.updateCalls++
+	return m.UpdateFn(ctx, alias, url)
+}
+
+func (m *repoMock) Revisions(ctx context.Context, alias string) ([]models.Revision, error) {
+	return nil, nil
+}
+
+func (m *repoMock) List(ctx context.Context, filter modellink.Filter) (*modellink.Page, error) {
+	return m.ListFn(ctx, filter)
+}
+
+func (m *repoMock) GetLinksByURL(ctx context.Context, owner string, urls []string) (map[string]modellink.Link, error) {
+	return m.GetLinksByURLFn(ctx, owner, urls)
+}
+
+func (m *repoMock) CreateBatch(ctx context.Context, links []modellink.Link) ([]error, error) {
+	m.batchCalls++
+	return m.CreateBatchFn(ctx, links)
+}
+
+func (m *repoMock) Replace(ctx context.Context, links []modellink.Link) ([]error, error) {
+	return m.ReplaceFn(ctx, links)
+}
+
+func (m *repoMock) GetAliasByURL(ctx context.Context, owner, url string) (string, error) {
+	m.lastAliasByURLOwner = owner
+	m.aliasByURLCall++
+	return m.GetAliasByURLFn(ctx, url)
+}
+
+func (m *repoMock) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	m.reapCalls++
+	return m.DeleteExpiredFn(ctx, now)
+}
+
+var testAliasConfig = config.AliasConfig{
+	Charset:   "abcdefghijklmnopqrstuvwxyz0123456789_",
+	MinLength: 3,
+	MaxLength: 16,
+	Reserved:  []string{"api", "metrics"},
+}
+
 func testLogger(buf *bytes.Buffer) *slog.Logger {
 	return slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
 }
 
-func Test_GetAlias_URLExistsError(t *testing.T) {
+func Test_GetAlias_ExistingURL(t *testing.T) {
 	var logBuf bytes.Buffer
 
-	wantErr := models.ErrDuplicate // должны совпасть
 	repo := &repoMock{
-		URLExistsFn: func(ctx context.Context, url string) (bool, error) {
-			return true, nil
+		GetAliasByURLFn: func(ctx context.Context, url string) (string, error) {
+			return "existing", nil
+		},
+		GetFn: func(ctx context.Context, alias string) (*modellink.Link, error) {
+			return &modellink.Link{URL: "https://bmstu.com", Alias: alias}, nil
 		},
 		CreateFn: func(ctx context.Context, url, alias string) error {
-			t.Fatalf("Create must not be called when URLExists fails")
+			t.Fatalf("Create must not be called when the URL is already stored")
 			return nil
 		},
 	}
 
-	s := New(repo, testLogger(&logBuf))
+	s := New(repo, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
 
-	alias, err := s.GetAlias(context.Background(), "https://bmstu.com")
-	if !errors.Is(err, wantErr) {
-		t.Fatalf("expected err=%v, got %v", wantErr, err)
+	link, created, err := s.GetAlias(context.Background(), modellink.Link{URL: "https://bmstu.com"})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if link.Alias != "existing" {
+		t.Fatalf("expected alias %q, got %q", "existing", link.Alias)
 	}
 
-	if alias != "" {
-		t.Fatalf("expected empty alias, got %q", alias)
+	if created {
+		t.Fatalf("expected created=false for an existing URL")
 	}
 
 	if repo.createCalls != 0 {
-		t.Fatalf("URLExists calls: want 1, got %d", repo.urlExistsCalls)
+		t.Fatalf("Create calls: want 0, got %d", repo.createCalls)
+	}
+}
+
+func Test_GetAlias_LookupError(t *testing.T) {
+	var logBuf bytes.Buffer
+
+	wantErr := errors.New("lookup failed")
+	repo := &repoMock{
+		GetAliasByURLFn: func(ctx context.Context, url string) (string, error) {
+			return "", wantErr
+		},
+		CreateFn: func(ctx context.Context, url, alias string) error {
+			t.Fatalf("Create must not be called when the lookup fails")
+			return nil
+		},
+	}
+
+	s := New(repo, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
+
+	_, _, err := s.GetAlias(context.Background(), modellink.Link{URL: "https://bmstu.com"})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("expected err=%v, got %v", wantErr, err)
 	}
 }
 
@@ -81,8 +190,8 @@ func Test_GetAlias_DuplicateAlias(t *testing.T) {
 
 	createAttempts := 0
 	repo := &repoMock{
-		URLExistsFn: func(ctx context.Context, url string) (bool, error) {
-			return false, nil
+		GetAliasByURLFn: func(ctx context.Context, url string) (string, error) {
+			return "", models.ErrNotFound
 		},
 		CreateFn: func(ctx context.Context, url, alias string) error {
 			createAttempts++
@@ -93,20 +202,97 @@ func Test_GetAlias_DuplicateAlias(t *testing.T) {
 		},
 	}
 
-	s := New(repo, testLogger(&logBuf))
+	s := New(repo, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
 
-	gotAlias, err := s.GetAlias(context.Background(), "https://sobaka.com")
+	collisionsBefore := aliasCollisions.Value()
+	createdBefore := linksCreated.With(sourceGenerated).Value()
+
+	gotLink, created, err := s.GetAlias(context.Background(), modellink.Link{URL: "https://sobaka.com"})
 	if err != nil {
 		t.Fatalf("unexpected err: %v", err)
 	}
 
-	if gotAlias == "" {
+	if !created {
+		t.Fatalf("expected created=true for a new URL")
+	}
+
+	if gotLink.Alias == "" {
 		t.Fatalf("expected non-empty alias")
 	}
 
 	if repo.createCalls != 3 {
 		t.Fatalf("Create calls: want 3, got %d", repo.createCalls)
 	}
+
+	if got := aliasCollisions.Value() - collisionsBefore; got != 2 {
+		t.Fatalf("collision retries: want 2, got %v", got)
+	}
+
+	if got := linksCreated.With(sourceGenerated).Value() - createdBefore; got != 1 {
+		t.Fatalf("generated links: want 1, got %v", got)
+	}
+}
+
+func Test_GetAlias_AttemptsExhausted(t *testing.T) {
+	var logBuf bytes.Buffer
+
+	repo := &repoMock{
+		GetAliasByURLFn: func(ctx context.Context, url string) (string, error) {
+			return "", models.ErrNotFound
+		},
+		CreateFn: func(ctx context.Context, url, alias string) error {
+			return models.ErrDuplicate
+		},
+	}
+
+	s := New(repo, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
+
+	link, created, err := s.GetAlias(context.Background(), modellink.Link{URL: "https://sobaka.com"})
+	if err == nil {
+		t.Fatalf("expected error after exhausting attempts")
+	}
+
+	if link != nil || created {
+		t.Fatalf("expected empty result, got link=%v created=%t", link, created)
+	}
+
+	if repo.createCalls != maxGenerateAttempts {
+		t.Fatalf("Create calls: want %d, got %d", maxGenerateAttempts, repo.createCalls)
+	}
+}
+
+func Test_GetAlias_HashGeneratorLengthensOnCollision(t *testing.T) {
+	var logBuf bytes.Buffer
+
+	var aliases []string
+	repo := &repoMock{
+		GetAliasByURLFn: func(ctx context.Context, url string) (string, error) {
+			return "", models.ErrNotFound
+		},
+		CreateFn: func(ctx context.Context, url, alias string) error {
+			aliases = append(aliases, alias)
+			if len(aliases) == 1 {
+				return models.ErrDuplicate
+			}
+			return nil
+		},
+	}
+
+	hashGenerator, err := generator.NewHash(6, "sha256")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	s := New(repo, hashGenerator, testLogger(&logBuf), testAliasConfig)
+
+	link, _, err := s.GetAlias(context.Background(), modellink.Link{URL: "https://sobaka.com"})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if len(aliases[0]) != 6 || len(link.Alias) != 7 || link.Alias[:6] != aliases[0] {
+		t.Fatalf("expected %q extended by one character, got %q", aliases[0], link.Alias)
+	}
 }
 
 func TestService_GetAlias_CreateError(t *testing.T) {
@@ -114,23 +300,23 @@ func TestService_GetAlias_CreateError(t *testing.T) {
 
 	wantErr := errors.New("insert failed")
 	repo := &repoMock{
-		URLExistsFn: func(ctx context.Context, url string) (bool, error) {
-			return false, nil
+		GetAliasByURLFn: func(ctx context.Context, url string) (string, error) {
+			return "", models.ErrNotFound
 		},
 		CreateFn: func(ctx context.Context, url, alias string) error {
 			return wantErr
 		},
 	}
 
-	s := New(repo, testLogger(&logBuf))
+	s := New(repo, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
 
-	alias, err := s.GetAlias(context.Background(), "https://what.com")
+	link, _, err := s.GetAlias(context.Background(), modellink.Link{URL: "https://what.com"})
 	if !errors.Is(err, wantErr) {
 		t.Fatalf("expected err=%v, got %v", wantErr, err)
 	}
 
-	if alias != "" {
-		t.Fatalf("expected empty alias, got %q", alias)
+	if link != nil {
+		t.Fatalf("expected nil link, got %v", link)
 	}
 
 	if logBuf.Len() == 0 {
@@ -138,60 +324,470 @@ func TestService_GetAlias_CreateError(t *testing.T) {
 	}
 }
 
-func TestService_GetURL_Success(t *testing.T) {
+func TestService_LogsWithRequestLogger(t *testing.T) {
+	var serviceBuf, requestBuf bytes.Buffer
+
+	repo := &repoMock{
+		GetAliasByURLFn: func(ctx context.Context, url string) (string, error) {
+			return "", errors.New("connection reset")
+		},
+	}
+
+	s := New(repo, generator.NewRandom(10), testLogger(&serviceBuf), testAliasConfig)
+
+	ctx := logging.WithLogger(context.Background(), testLogger(&requestBuf).With("request_id", "req-1"))
+	if _, _, err := s.GetAlias(ctx, modellink.Link{URL: "https://what.com"}); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	if serviceBuf.Len() != 0 {
+		t.Fatalf("expected no output on the service logger, got %q", serviceBuf.String())
+	}
+
+	if !bytes.Contains(requestBuf.Bytes(), []byte("request_id=req-1")) {
+		t.Fatalf("expected request-scoped log, got %q", requestBuf.String())
+	}
+}
+
+func TestService_GetLink_Success(t *testing.T) {
 	var logBuf bytes.Buffer
 
 	repo := &repoMock{
-		GetFn: func(ctx context.Context, alias string) (string, error) {
+		GetFn: func(ctx context.Context, alias string) (*modellink.Link, error) {
 			if alias != "abc" {
 				t.Fatalf("expected alias abc, got %q", alias)
 			}
-			return "https://lostmary.com", nil
+			return &modellink.Link{URL: "https://lostmary.com", Alias: alias}, nil
 		},
 	}
 
-	s := New(repo, testLogger(&logBuf))
+	s := New(repo, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
 
-	url, err := s.GetURL(context.Background(), "abc")
+	link, err := s.GetLink(context.Background(), "abc")
 	if err != nil {
 		t.Fatalf("unexpected err: %v", err)
 	}
-	if url != "https://lostmary.com" {
-		t.Fatalf("expected url %q, got %q", "https://lostmary.com", url)
+	if link.URL != "https://lostmary.com" {
+		t.Fatalf("expected url %q, got %q", "https://lostmary.com", link.URL)
 	}
 	if repo.getCalls != 1 {
 		t.Fatalf("Get calls: want 1, got %d", repo.getCalls)
 	}
 }
 
-func TestService_GetURL_Error(t *testing.T) {
+func TestService_GetLink_Expired(t *testing.T) {
+	var logBuf bytes.Buffer
+
+	expiresAt := time.Now().Add(-time.Minute)
+	repo := &repoMock{
+		GetFn: func(ctx context.Context, alias string) (*modellink.Link, error) {
+			return &modellink.Link{URL: "https://lostmary.com", Alias: alias, ExpiresAt: &expiresAt}, nil
+		},
+	}
+
+	s := New(repo, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
+
+	link, err := s.GetLink(context.Background(), "abc")
+	if !errors.Is(err, models.ErrExpired) {
+		t.Fatalf("expected err=%v, got %v", models.ErrExpired, err)
+	}
+	if link != nil {
+		t.Fatalf("expected nil link, got %v", link)
+	}
+}
+
+func TestService_GetLink_Disabled(t *testing.T) {
+	var logBuf bytes.Buffer
+
+	repo := &repoMock{
+		GetFn: func(ctx context.Context, alias string) (*modellink.Link, error) {
+			return &modellink.Link{URL: "https://lostmary.com", Alias: alias, Disabled: true}, nil
+		},
+	}
+
+	s := New(repo, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
+
+	link, err := s.GetLink(context.Background(), "abc")
+	if !errors.Is(err, models.ErrDisabled) {
+		t.Fatalf("expected err=%v, got %v", models.ErrDisabled, err)
+	}
+	if link != nil {
+		t.Fatalf("expected nil link, got %v", link)
+	}
+}
+
+func TestService_GetLink_Error(t *testing.T) {
 	var logBuf bytes.Buffer
 
 	wantErr := errors.New("not found")
 	repo := &repoMock{
-		GetFn: func(ctx context.Context, alias string) (string, error) {
-			return "", wantErr
+		GetFn: func(ctx context.Context, alias string) (*modellink.Link, error) {
+			return nil, wantErr
 		},
 		URLExistsFn: func(ctx context.Context, url string) (bool, error) {
-			t.Fatalf("URLExists not expected in GetURL")
+			t.Fatalf("URLExists not expected in GetLink")
 			return false, nil
 		},
 		CreateFn: func(ctx context.Context, url, alias string) error {
-			t.Fatalf("Create not expected in GetURL")
+			t.Fatalf("Create not expected in GetLink")
 			return nil
 		},
 	}
 
-	s := New(repo, testLogger(&logBuf))
+	s := New(repo, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
 
-	url, err := s.GetURL(context.Background(), "abc")
+	link, err := s.GetLink(context.Background(), "abc")
 	if !errors.Is(err, wantErr) {
 		t.Fatalf("expected err=%v, got %v", wantErr, err)
 	}
-	if url != "" {
-		t.Fatalf("expected empty url, got %q", url)
+	if link != nil {
+		t.Fatalf("expected nil link, got %v", link)
 	}
 	if logBuf.Len() == 0 {
 		t.Fatalf("expected log output, got empty")
 	}
 }
+
+func TestService_DeleteAlias(t *testing.T) {
+	var logBuf bytes.Buffer
+
+	repo := &repoMock{
+		DeleteFn: func(ctx context.Context, alias string) error {
+			if alias != "abc" {
+				t.Fatalf("expected alias abc, got %q", alias)
+			}
+			return models.ErrNotFound
+		},
+	}
+
+	s := New(repo, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
+
+	err := s.DeleteAlias(context.Background(), "abc")
+	if !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("expected err=%v, got %v", models.ErrNotFound, err)
+	}
+	if repo.deleteCalls != 1 {
+		t.Fatalf("Delete calls: want 1, got %d", repo.deleteCalls)
+	}
+}
+
+func TestService_DeleteAlias_Ownership(t *testing.T) {
+	tests := []struct {
+		name        string
+		principal   *models.Principal
+		wantErr     error
+		wantDeletes int
+	}{
+		{name: "anonymous_without_auth", wantDeletes: 1},
+		{name: "owner", principal: &models.Principal{Owner: "grace"}, wantDeletes: 1},
+		{name: "other_owner", principal: &models.Principal{Owner: "tom"}, wantErr: models.ErrForbidden},
+		{name: "admin", principal: &models.Principal{Owner: "ops", Admin: true}, wantDeletes: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logBuf bytes.Buffer
+
+			repo := &repoMock{
+				GetFn: func(ctx context.Context, alias string) (*modellink.Link, error) {
+					return &modellink.Link{URL: "https://dogville.com", Alias: alias, Owner: "grace"}, nil
+				},
+				DeleteFn: func(ctx context.Context, alias string) error {
+					return nil
+				},
+			}
+
+			s := New(repo, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
+
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = auth.WithPrincipal(ctx, *tt.principal)
+			}
+
+			err := s.DeleteAlias(ctx, "abc")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected err=%v, got %v", tt.wantErr, err)
+			}
+			if repo.deleteCalls != tt.wantDeletes {
+				t.Fatalf("Delete calls: want %d, got %d", tt.wantDeletes, repo.deleteCalls)
+			}
+		})
+	}
+}
+
+func TestService_GetOwnedLink(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *models.Principal
+		wantErr   error
+	}{
+		{name: "anonymous_without_auth"},
+		{name: "owner", principal: &models.Principal{Owner: "grace"}},
+		{name: "other_owner", principal: &models.Principal{Owner: "tom"}, wantErr: models.ErrForbidden},
+		{name: "admin", principal: &models.Principal{Owner: "ops", Admin: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logBuf bytes.Buffer
+
+			past := time.Now().Add(-time.Hour)
+			repo := &repoMock{
+				GetFn: func(ctx context.Context, alias string) (*modellink.Link, error) {
+					return &modellink.Link{URL: "https://dogville.com", Alias: alias, Owner: "grace", ExpiresAt: &past, Disabled: true}, nil
+				},
+			}
+
+			s := New(repo, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
+
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = auth.WithPrincipal(ctx, *tt.principal)
+			}
+
+			link, err := s.GetOwnedLink(ctx, "abc")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected err=%v, got %v", tt.wantErr, err)
+			}
+			if err == nil && link.Alias != "abc" {
+				t.Fatalf("expected the expired, disabled link, got %+v", link)
+			}
+		})
+	}
+}
+
+func TestService_SetAliasDisabled_Ownership(t *testing.T) {
+	tests := []struct {
+		name         string
+		principal    *models.Principal
+		wantErr      error
+		wantDisables int
+	}{
+		{name: "owner", principal: &models.Principal{Owner: "grace"}, wantDisables: 1},
+		{name: "other_owner", principal: &models.Principal{Owner: "tom"}, wantErr: models.ErrForbidden},
+		{name: "admin", principal: &models.Principal{Owner: "ops", Admin: true}, wantDisables: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logBuf bytes.Buffer
+
+			repo := &repoMock{
+				GetFn: func(ctx context.Context, alias string) (*modellink.Link, error) {
+					return &modellink.Link{URL: "https://dogville.com", Alias: alias, Owner: "grace"}, nil
+				},
+				DisableFn: func(ctx context.Context, alias string, disabled bool) error {
+					if !disabled {
+						t.Fatalf("expected disabled=true")
+					}
+					return nil
+				},
+			}
+
+			s := New(repo, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
+
+			ctx := auth.WithPrincipal(context.Background(), *tt.principal)
+
+			err := s.SetAliasDisabled(ctx, "abc", true)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected err=%v, got %v", tt.wantErr, err)
+			}
+			if repo.disableCalls != tt.wantDisables {
+				t.Fatalf("SetDisabled calls: want %d, got %d", tt.wantDisables, repo.disableCalls)
+			}
+		})
+	}
+}
+
+func TestService_UpdateAlias_Ownership(t *testing.T) {
+	tests := []struct {
+		name        string
+		principal   *models.Principal
+		wantErr     error
+		wantUpdates int
+	}{
+		{name: "anonymous_without_auth", wantUpdates: 1},
+		{name: "owner", principal: &models.Principal{Owner: "grace"}, wantUpdates: 1},
+		{name: "other_owner", principal: &models.Principal{Owner: "tom"}, wantErr: models.ErrForbidden},
+		{name: "admin", principal: &models.Principal{Owner: "ops", Admin: true}, wantUpdates: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logBuf bytes.Buffer
+
+			repo := &repoMock{
+				GetFn: func(ctx context.Context, alias string) (*modellink.Link, error) {
+					return &modellink.Link{URL: "https://dogville.com", Alias: alias, Owner: "grace"}, nil
+				},
+				UpdateFn: func(ctx context.Context, alias, url string) (*modellink.Link, error) {
+					return &modellink.Link{URL: url, Alias: alias, Owner: "grace"}, nil
+				},
+			}
+
+			s := New(repo, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
+
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = auth.WithPrincipal(ctx, *tt.principal)
+			}
+
+			link, err := s.UpdateAlias(ctx, "abc", "https://manderlay.com")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected err=%v, got %v", tt.wantErr, err)
+			}
+			if repo.updateCalls != tt.wantUpdates {
+				t.Fatalf("Update calls: want %d, got %d", tt.wantUpdates, repo.updateCalls)
+			}
+			if err == nil && link.URL != "https://manderlay.com" {
+				t.Fatalf("expected updated URL, got %q", link.URL)
+			}
+		})
+	}
+}
+
+func TestService_ListLinks_ScopedToOwner(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *models.Principal
+		owner     string
+		wantOwner string
+		wantErr   error
+	}{
+		{name: "anonymous_without_auth", owner: "tom", wantOwner: "tom"},
+		{name: "own_links", principal: &models.Principal{Owner: "grace"}, wantOwner: "grace"},
+		{name: "explicit_own_owner", principal: &models.Principal{Owner: "grace"}, owner: "grace", wantOwner: "grace"},
+		{name: "other_owner", principal: &models.Principal{Owner: "grace"}, owner: "tom", wantErr: models.ErrForbidden},
+		{name: "admin_all", principal: &models.Principal{Owner: "ops", Admin: true}},
+		{name: "admin_other_owner", principal: &models.Principal{Owner: "ops", Admin: true}, owner: "tom", wantOwner: "tom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logBuf bytes.Buffer
+
+			var listed *modellink.Filter
+			repo := &repoMock{
+				ListFn: func(ctx context.Context, filter modellink.Filter) (*modellink.Page, error) {
+					listed = &filter
+					return &modellink.Page{}, nil
+				},
+			}
+
+			s := New(repo, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
+
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = auth.WithPrincipal(ctx, *tt.principal)
+			}
+
+			_, err := s.ListLinks(ctx, modellink.Filter{Owner: tt.owner})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected err=%v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr != nil {
+				if listed != nil {
+					t.Fatalf("List not expected")
+				}
+				return
+			}
+			if listed.Owner != tt.wantOwner {
+				t.Fatalf("expected owner filter %q, got %q", tt.wantOwner, listed.Owner)
+			}
+		})
+	}
+}
+
+func Test_GetAlias_ScopedToOwner(t *testing.T) {
+	var logBuf bytes.Buffer
+
+	repo := &repoMock{
+		GetAliasByURLFn: func(ctx context.Context, url string) (string, error) {
+			return "", models.ErrNotFound
+		},
+		CreateFn: func(ctx context.Context, url, alias string) error {
+			return nil
+		},
+	}
+
+	s := New(repo, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
+
+	link, _, err := s.GetAlias(context.Background(), modellink.Link{URL: "https://dogville.com", Owner: "grace"})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if repo.lastAliasByURLOwner != "grace" {
+		t.Fatalf("expected lookup scoped to owner grace, got %q", repo.lastAliasByURLOwner)
+	}
+	if link.Owner != "grace" || repo.lastCreateLink.Owner != "grace" {
+		t.Fatalf("expected owner to be stored, got %q", repo.lastCreateLink.Owner)
+	}
+}
+
+func TestService_CreateAlias(t *testing.T) {
+	tests := []struct {
+		name        string
+		alias       string
+		createErr   error
+		wantErr     error
+		wantCreates int
+	}{
+		{name: "success", alias: "promo_2024", wantCreates: 1},
+		{name: "too_short", alias: "ab", wantErr: models.ErrValidation},
+		{name: "too_long", alias: "abcdefghijklmnopq", wantErr: models.ErrValidation},
+		{name: "forbidden_char", alias: "promo-2024", wantErr: models.ErrValidation},
+		{name: "reserved", alias: "Metrics", wantErr: models.ErrValidation},
+		{name: "taken", alias: "promo", createErr: models.ErrDuplicate, wantErr: models.ErrAliasTaken, wantCreates: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logBuf bytes.Buffer
+
+			repo := &repoMock{
+				CreateFn: func(ctx context.Context, url, alias string) error {
+					return tt.createErr
+				},
+			}
+
+			s := New(repo, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
+
+			err := s.CreateAlias(context.Background(), modellink.Link{URL: "https://example.com", Alias: tt.alias})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected err=%v, got %v", tt.wantErr, err)
+			}
+			if repo.createCalls != tt.wantCreates {
+				t.Fatalf("Create calls: want %d, got %d", tt.wantCreates, repo.createCalls)
+			}
+			if tt.wantCreates == 1 && repo.lastCreateAlias != tt.alias {
+				t.Fatalf("expected alias %q, got %q", tt.alias, repo.lastCreateAlias)
+			}
+		})
+	}
+}
+
+func Test_GetAlias_KeepsExpiry(t *testing.T) {
+	var logBuf bytes.Buffer
+
+	repo := &repoMock{
+		GetAliasByURLFn: func(ctx context.Context, url string) (string, error) {
+			return "", models.ErrNotFound
+		},
+		CreateFn: func(ctx context.Context, url, alias string) error {
+			return nil
+		},
+	}
+
+	s := New(repo, generator.NewRandom(10), testLogger(&logBuf), testAliasConfig)
+
+	expiresAt := time.Now().Add(time.Hour)
+	link, _, err := s.GetAlias(context.Background(), modellink.Link{URL: "https://sobaka.com", ExpiresAt: &expiresAt})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if repo.lastCreateLink.ExpiresAt != &expiresAt || link.ExpiresAt != &expiresAt {
+		t.Fatalf("expected expiry to be stored and returned")
+	}
+}